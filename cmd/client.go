@@ -0,0 +1,138 @@
+package cmd
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "dtctl/pkg/config"
+    "dtctl/pkg/dependencytrack"
+)
+
+// ClientFactory returns the Dependency-Track API that commands talk to.
+type ClientFactory func() (dependencytrack.API, error)
+
+// clientFactory is consulted by every command that talks to the server.
+var clientFactory ClientFactory = defaultClient
+
+// SetClientFactory replaces how commands obtain their Dependency-Track API,
+// e.g. to run them against a fake backend. nil restores the default, which
+// connects to the server of the current context.
+func SetClientFactory(f ClientFactory) {
+    if f == nil {
+        f = defaultClient
+    }
+    clientFactory = f
+}
+
+// newClient returns the API the running command should use.
+func newClient() (dependencytrack.API, error) {
+    return clientFactory()
+}
+
+// replayURL is the base URL used when replaying a cassette without a current
+// context; the host of replayed requests is never contacted.
+const replayURL = "http://replay.invalid"
+
+// defaultClient builds a Dependency-Track client for the current context,
+// applying the global request settings.
+func defaultClient() (dependencytrack.API, error) {
+    if recordFile != "" && replayFile != "" {
+        return nil, fmt.Errorf("--record and --replay cannot be used together")
+    }
+
+    ctx, err := config.ResolveContext(config.Overrides{
+        Context: contextName,
+        Server:  serverURL,
+        Token:   apiToken,
+    })
+    if err == config.ErrNoContext && replayFile != "" {
+        ctx, err = &config.Context{URL: replayURL}, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    client, err := contextClient(ctx)
+    if err != nil {
+        return nil, err
+    }
+    switch {
+    case recordFile != "":
+        client.HTTPClient.Transport = dependencytrack.NewRecorder(recordFile, client.HTTPClient.Transport)
+    case replayFile != "":
+        replayer, err := dependencytrack.NewReplayer(replayFile)
+        if err != nil {
+            return nil, err
+        }
+        client.HTTPClient.Transport = replayer
+        // Retries recorded in the cassette are replayed as they happened,
+        // without waiting between them.
+        client.Retry.MinBackoff = 0
+        client.Retry.MaxBackoff = time.Nanosecond
+        client.Retry.IgnoreRetryAfter = true
+    }
+    traceClient(client)
+    return client, nil
+}
+
+// contextClient builds a client for ctx, whose Token must already be
+// resolved, applying the global request settings.
+func contextClient(ctx *config.Context) (*dependencytrack.Client, error) {
+    client := dependencytrack.NewClient(ctx.URL, ctx.Token)
+    transport, err := dependencytrack.NewTransport(transportConfig(ctx))
+    if err != nil {
+        return nil, fmt.Errorf("invalid connection settings for context '%s': %w", ctx.Name, err)
+    }
+    client.HTTPClient.Transport = transport
+    client.HTTPClient.Timeout = requestTimeout
+    client.PageSize = pageSize
+    if ctx.MaxRetries != nil {
+        client.Retry.MaxRetries = *ctx.MaxRetries
+    }
+    if rootCmd.PersistentFlags().Changed("max-retries") {
+        client.Retry.MaxRetries = maxRetries
+    }
+    client.Retry.RetryUpdates = ctx.RetryUpdates
+    return client, nil
+}
+
+// traceClient logs the requests of client when -v is given.
+func traceClient(client *dependencytrack.Client) {
+    if verbosity > 0 && logger != nil {
+        client.HTTPClient.Transport = &dependencytrack.TracingTransport{
+            Next:      client.HTTPClient.Transport,
+            Logger:    logger,
+            Verbosity: verbosity,
+        }
+    }
+}
+
+// transportConfig returns the TLS and proxy settings of ctx.
+func transportConfig(ctx *config.Context) dependencytrack.TransportConfig {
+    return dependencytrack.TransportConfig{
+        CAFile:             ctx.CertificateAuthority,
+        CAData:             ctx.CertificateAuthorityData,
+        CertFile:           ctx.ClientCertificate,
+        CertData:           ctx.ClientCertificateData,
+        KeyFile:            ctx.ClientKey,
+        KeyData:            ctx.ClientKeyData,
+        InsecureSkipVerify: ctx.InsecureSkipTLSVerify,
+        ServerName:         ctx.ServerName,
+        ProxyURL:           ctx.ProxyURL,
+    }
+}
+
+// walkProjects pages through all projects, or only those carrying tag when it
+// is set.
+func walkProjects(ctx context.Context, client dependencytrack.API, tag string, fn func([]dependencytrack.Project) error) error {
+    if tag != "" {
+        return client.WalkProjectsByTagContext(ctx, tag, fn)
+    }
+    return client.WalkProjectsContext(ctx, fn)
+}
+
+// interruptedError reports how far a per-project walk got before ctx ended.
+func interruptedError(ctx context.Context, done, total int) error {
+    return fmt.Errorf("interrupted after %d of %d projects: %v", done, total, ctx.Err())
+}
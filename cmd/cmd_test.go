@@ -0,0 +1,327 @@
+package cmd
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "os"
+    "strings"
+    "testing"
+
+    "github.com/spf13/cobra"
+    "github.com/spf13/pflag"
+    "dtctl/pkg/dependencytrack"
+    "dtctl/pkg/dependencytrack/fake"
+)
+
+// newFakeServer starts a fake Dependency-Track server seeded with
+// testdata/fixtures.json and points the commands at it.
+func newFakeServer(t *testing.T) *fake.Server {
+    t.Helper()
+    f, err := os.Open("testdata/fixtures.json")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    fixtures, err := fake.LoadFixtures(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    srv := fake.NewServer(fixtures)
+    SetClientFactory(func() (dependencytrack.API, error) {
+        client := srv.Client()
+        client.PageSize = pageSize
+        return client, nil
+    })
+    t.Cleanup(func() {
+        SetClientFactory(nil)
+        srv.Close()
+    })
+    return srv
+}
+
+// runCommand runs dtctl with args and returns what it wrote to stdout.
+func runCommand(t *testing.T, args ...string) (string, error) {
+    t.Helper()
+    resetFlags(rootCmd)
+
+    var out, errOut bytes.Buffer
+    rootCmd.SetOut(&out)
+    rootCmd.SetErr(&errOut)
+    rootCmd.SetArgs(args)
+    err := rootCmd.ExecuteContext(context.Background())
+    return out.String(), err
+}
+
+// resetFlags restores every flag of cmd and its subcommands to its default,
+// since flag values live in package variables shared between runs.
+func resetFlags(cmd *cobra.Command) {
+    reset := func(f *pflag.Flag) {
+        f.Value.Set(f.DefValue)
+        f.Changed = false
+    }
+    cmd.Flags().VisitAll(reset)
+    cmd.PersistentFlags().VisitAll(reset)
+    for _, sub := range cmd.Commands() {
+        resetFlags(sub)
+    }
+}
+
+func TestGetProjects(t *testing.T) {
+    newFakeServer(t)
+
+    out, err := runCommand(t, "get", "projects", "--tag", "prod", "-o", "name")
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := "project/11111111-0000-0000-0000-000000000001\nproject/11111111-0000-0000-0000-000000000002\n"
+    if out != want {
+        t.Errorf("got %q, want %q", out, want)
+    }
+}
+
+func TestGetProjectsPaging(t *testing.T) {
+    srv := newFakeServer(t)
+
+    out, err := runCommand(t, "get", "projects", "--page-size", "1", "-o", "name")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n := strings.Count(out, "project/"); n != 3 {
+        t.Errorf("got %d projects, want 3:\n%s", n, out)
+    }
+    if n := len(srv.Requests()); n != 3 {
+        t.Errorf("got %d requests, want one per page (3)", n)
+    }
+}
+
+func TestGetComponentsKeepsProjectOrder(t *testing.T) {
+    newFakeServer(t)
+
+    out, err := runCommand(t, "get", "components", "-o", "json")
+    if err != nil {
+        t.Fatal(err)
+    }
+    var list struct {
+        Items []dependencytrack.Component `json:"items"`
+    }
+    if err := json.Unmarshal([]byte(out), &list); err != nil {
+        t.Fatalf("invalid JSON output: %v\n%s", err, out)
+    }
+    var names []string
+    for _, c := range list.Items {
+        names = append(names, c.Project.Name+"/"+c.Name)
+    }
+    got := strings.Join(names, " ")
+    want := "billing/log4j-core billing/jackson-databind gateway/express"
+    if got != want {
+        t.Errorf("got %s, want %s", got, want)
+    }
+}
+
+func TestGetComponentsLimitStopsWorkers(t *testing.T) {
+    srv := newFakeServer(t)
+    for p := 0; p < 8; p++ {
+        uuid := fmt.Sprintf("11111111-0000-0000-0001-%012d", p)
+        srv.AddProject(dependencytrack.Project{Name: fmt.Sprint("bulk", p), UUID: uuid})
+        for c := 0; c < 10; c++ {
+            srv.AddComponent(uuid, dependencytrack.Component{Name: fmt.Sprintf("bulk%d-%d", p, c), UUID: fmt.Sprintf("22222222-0000-0000-1%03d-%012d", p, c)})
+        }
+    }
+
+    out, err := runCommand(t, "get", "components", "--limit", "5", "--page-size", "2", "--concurrency", "4", "-o", "name")
+    if err != nil {
+        t.Fatal(err)
+    }
+    // The first five components in project order: billing and gateway
+    // hold three, the first bulk project the rest.
+    want := "component/22222222-0000-0000-0000-000000000001\ncomponent/22222222-0000-0000-0000-000000000002\ncomponent/22222222-0000-0000-0000-000000000003\n" +
+        "component/22222222-0000-0000-1000-000000000000\ncomponent/22222222-0000-0000-1000-000000000001\n"
+    if out != want {
+        t.Errorf("got:\n%s\nwant:\n%s", out, want)
+    }
+    pages := 0
+    for _, r := range srv.Requests() {
+        if strings.HasPrefix(r.Path, "/api/v1/component/project/") {
+            pages++
+        }
+    }
+    // Without a global limit every worker fetched up to five components of
+    // its project, three pages each.
+    if pages > 10 {
+        t.Errorf("%d component pages fetched for 5 components", pages)
+    }
+}
+
+func TestGetComponentsTableAlignsAcrossProjects(t *testing.T) {
+    srv := newFakeServer(t)
+    uuid := "11111111-0000-0000-0001-000000000000"
+    srv.AddProject(dependencytrack.Project{Name: "late", UUID: uuid})
+    srv.AddComponent(uuid, dependencytrack.Component{Name: "a-much-longer-component-name", UUID: "22222222-0000-0000-1000-000000000000"})
+
+    out, err := runCommand(t, "get", "components", "--concurrency", "1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+    column := strings.Index(lines[0], "COMPONENT UUID")
+    for _, line := range lines[2:] {
+        if i := strings.Index(line, "22222222-"); i != column {
+            t.Errorf("UUID at column %d, want %d:\n%s", i, column, out)
+            break
+        }
+    }
+}
+
+func TestGetComponentsFailurePrintsNothing(t *testing.T) {
+    for _, format := range []string{"table", "name", "csv", "json", "yaml"} {
+        srv := newFakeServer(t)
+        srv.InjectFault(fake.Fault{Path: "/api/v1/component/project/11111111-0000-0000-0000-000000000002", Status: http.StatusBadGateway})
+
+        out, err := runCommand(t, "get", "components", "--concurrency", "1", "-o", format)
+        if err == nil {
+            t.Fatalf("-o %s: expected an error for the failed project", format)
+        }
+        if out != "" {
+            t.Errorf("-o %s: printed output despite the failure:\n%s", format, out)
+        }
+    }
+}
+
+func TestGetComponentsKeepGoingStreamsBeforeFailure(t *testing.T) {
+    srv := newFakeServer(t)
+    srv.InjectFault(fake.Fault{Path: "/api/v1/component/project/11111111-0000-0000-0000-000000000002", Status: http.StatusBadGateway})
+
+    out, err := runCommand(t, "get", "components", "--concurrency", "1", "--keep-going", "-o", "name")
+    if err == nil {
+        t.Fatal("expected an error for the failed project")
+    }
+    if !strings.Contains(out, "component/22222222-0000-0000-0000-000000000001") {
+        t.Errorf("components of the other projects were not printed:\n%s", out)
+    }
+}
+
+func TestKeepGoingReportsMostSevereFailure(t *testing.T) {
+    billing := "/api/v1/component/project/11111111-0000-0000-0000-000000000001"
+    gateway := "/api/v1/component/project/11111111-0000-0000-0000-000000000002"
+    for _, faults := range [][2]string{{billing, gateway}, {gateway, billing}} {
+        srv := newFakeServer(t)
+        srv.InjectFault(fake.Fault{Path: faults[0], Status: http.StatusNotFound})
+        srv.InjectFault(fake.Fault{Path: faults[1], Status: http.StatusBadGateway})
+
+        _, err := runCommand(t, "get", "components", "--keep-going")
+        var apiErr *dependencytrack.APIError
+        if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+            t.Errorf("404 on %s: got error %v, want the 502", faults[0], err)
+        }
+    }
+}
+
+func TestGetComponentsServerError(t *testing.T) {
+    srv := newFakeServer(t)
+    srv.InjectFault(fake.Fault{Path: "/api/v1/component/project/", Status: http.StatusInternalServerError})
+
+    _, err := runCommand(t, "get", "components")
+    var apiErr *dependencytrack.APIError
+    if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+        t.Fatalf("got error %v, want an APIError with status 500", err)
+    }
+}
+
+func TestSetComponent(t *testing.T) {
+    srv := newFakeServer(t)
+
+    sum := strings.Repeat("f", 64)
+    out, err := runCommand(t, "set", "component", "--uuid", "22222222-0000-0000-0000-000000000003", "--field-sha256", sum)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if out != "Component sha256 updated successfully.\n" {
+        t.Errorf("unexpected output %q", out)
+    }
+    for _, c := range srv.Fixtures().Components {
+        if c.UUID == "22222222-0000-0000-0000-000000000003" && c.Sha256 != sum {
+            t.Errorf("sha256 is %q, want %q", c.Sha256, sum)
+        }
+    }
+}
+
+func TestSetHashPolicyCondition(t *testing.T) {
+    srv := newFakeServer(t)
+
+    sum := strings.Repeat("e", 64)
+    _, err := runCommand(t, "set", "hashpolicycondition",
+        "--uuid", "44444444-0000-0000-0000-000000000001",
+        "--operator", "IS_NOT", "--algorithm", "SHA-256", "--algorithm-value", sum)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    reqs := srv.Requests()
+    last := reqs[len(reqs)-1]
+    if last.Method != "POST" || last.Path != "/api/v1/policy/condition" {
+        t.Fatalf("last request was %s %s", last.Method, last.Path)
+    }
+    condition := srv.Fixtures().Policies[0].PolicyConditions[0]
+    if condition.Operator != "IS_NOT" || condition.Hash == nil || condition.Hash.Value != sum {
+        t.Errorf("condition not updated: %+v", condition)
+    }
+}
+
+func TestEvalPolicy(t *testing.T) {
+    newFakeServer(t)
+
+    out, err := runCommand(t, "eval", "policy", "--uuid", "33333333-0000-0000-0000-000000000001")
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, want := range []string{
+        "banned-log4j  log4j-core        VIOLATED",
+        "banned-log4j  jackson-databind  NOT VIOLATED",
+        "banned-log4j  express           NOT VIOLATED",
+    } {
+        if !strings.Contains(out, want) {
+            t.Errorf("output is missing %q:\n%s", want, out)
+        }
+    }
+}
+
+func TestEvalPolicyLogsScope(t *testing.T) {
+    newFakeServer(t)
+
+    resetFlags(rootCmd)
+    var out, errOut bytes.Buffer
+    rootCmd.SetOut(&out)
+    rootCmd.SetErr(&errOut)
+    rootCmd.SetArgs([]string{"eval", "policy", "--uuid", "33333333-0000-0000-0000-000000000002", "-v", "1"})
+    if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    for _, want := range []string{
+        `msg="policy scope" project=billing version=1.2.0 uuid=11111111-0000-0000-0000-000000000001 reason="global policy"`,
+        `msg="policy scope" project=sandbox version=0.1.0 uuid=11111111-0000-0000-0000-000000000003 reason="global policy"`,
+    } {
+        if !strings.Contains(errOut.String(), want) {
+            t.Errorf("log is missing %q:\n%s", want, errOut.String())
+        }
+    }
+}
+
+func TestCheckContextTLS(t *testing.T) {
+    srv := fake.NewTLSServer(fake.Fixtures{})
+    defer srv.Close()
+
+    var out bytes.Buffer
+    if err := checkContext(context.Background(), &out, "secure", srv.Client()); err != nil {
+        t.Fatal(err)
+    }
+    for _, want := range []string{"Context:    secure", "TLS 1.3", "certificate example.com issued by O=Acme Co"} {
+        if !strings.Contains(out.String(), want) {
+            t.Errorf("output does not contain %q:\n%s", want, out.String())
+        }
+    }
+}
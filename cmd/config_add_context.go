@@ -8,16 +8,23 @@ import (
 )
 
 var (
-    url   string
-    token string
+    url            string
+    retries        int
+    retryUpdates   bool
+    addVerify      bool
+    addConnection  connectionFlags
+    addCredentials credentialFlags
 )
 
 func init() {
     configCmd.AddCommand(addContextCmd)
     addContextCmd.Flags().StringVar(&url, "url", "", "Dependency-Track server URL")
-    addContextCmd.Flags().StringVar(&token, "token", "", "API token")
+    addCredentials.register(addContextCmd)
+    addContextCmd.Flags().IntVar(&retries, "context-max-retries", 0, "Number of retries for transient failures (default: client default)")
+    addContextCmd.Flags().BoolVar(&retryUpdates, "retry-updates", false, "Also retry the POST requests made by set commands")
+    addConnection.register(addContextCmd)
+    addContextCmd.Flags().BoolVar(&addVerify, "verify", false, "Check the server and token with 'config test-context' before saving")
     addContextCmd.MarkFlagRequired("url")
-    addContextCmd.MarkFlagRequired("token")
 }
 
 var addContextCmd = &cobra.Command{
@@ -27,14 +34,28 @@ var addContextCmd = &cobra.Command{
     RunE: func(cmd *cobra.Command, args []string) error {
         name := args[0]
         ctx := config.Context{
-            Name:  name,
-            URL:   url,
-            Token: token,
+            Name: name,
+            URL:  url,
+        }
+        if cmd.Flags().Changed("context-max-retries") {
+            ctx.MaxRetries = &retries
+        }
+        ctx.RetryUpdates = retryUpdates
+        if err := addCredentials.apply(cmd, &ctx, true); err != nil {
+            return err
+        }
+        if err := addConnection.apply(cmd, &ctx); err != nil {
+            return err
+        }
+        if addVerify {
+            if err := verifyContext(cmd, ctx); err != nil {
+                return err
+            }
         }
         if err := config.AddContext(ctx); err != nil {
             return err
         }
-        fmt.Printf("Context '%s' added successfully.\n", name)
+        fmt.Fprintf(cmd.OutOrStdout(), "Context '%s' added successfully.\n", name)
         return nil
     },
 }
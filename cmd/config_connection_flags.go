@@ -0,0 +1,105 @@
+package cmd
+
+import (
+    "fmt"
+    "io/ioutil"
+    "path/filepath"
+
+    "github.com/spf13/cobra"
+    "dtctl/pkg/config"
+    "dtctl/pkg/dependencytrack"
+)
+
+// connectionFlags are the TLS and proxy flags shared by add-context and
+// edit-context.
+type connectionFlags struct {
+    certificateAuthority  string
+    clientCertificate     string
+    clientKey             string
+    embedCerts            bool
+    insecureSkipTLSVerify bool
+    proxyURL              string
+    serverName            string
+}
+
+// connectionFlagNames lists the flags registered by connectionFlags.
+var connectionFlagNames = []string{
+    "certificate-authority", "client-certificate", "client-key", "embed-certs",
+    "insecure-skip-tls-verify", "proxy-url", "server-name",
+}
+
+func (f *connectionFlags) register(cmd *cobra.Command) {
+    cmd.Flags().StringVar(&f.certificateAuthority, "certificate-authority", "", "PEM file with extra certificate authorities to trust")
+    cmd.Flags().StringVar(&f.clientCertificate, "client-certificate", "", "PEM file with the client certificate for mutual TLS")
+    cmd.Flags().StringVar(&f.clientKey, "client-key", "", "PEM file with the client key for mutual TLS")
+    cmd.Flags().BoolVar(&f.embedCerts, "embed-certs", false, "Store the contents of the certificate and key files in the config instead of their paths")
+    cmd.Flags().BoolVar(&f.insecureSkipTLSVerify, "insecure-skip-tls-verify", false, "Do not verify the server's certificate (insecure)")
+    cmd.Flags().StringVar(&f.proxyURL, "proxy-url", "", "URL of the proxy to send requests through")
+    cmd.Flags().StringVar(&f.serverName, "server-name", "", "Name to verify the server's certificate against, if it differs from the URL's host")
+}
+
+// changed reports whether any connection flag was given.
+func (f *connectionFlags) changed(cmd *cobra.Command) bool {
+    for _, name := range connectionFlagNames {
+        if cmd.Flags().Changed(name) {
+            return true
+        }
+    }
+    return false
+}
+
+// apply copies the given connection flags into ctx and checks that the
+// resulting settings are usable. An empty value clears a setting.
+func (f *connectionFlags) apply(cmd *cobra.Command, ctx *config.Context) error {
+    changed := cmd.Flags().Changed
+    setFile := func(flag, value string, file *string, data *[]byte) error {
+        if !changed(flag) {
+            // --embed-certs on its own embeds the files already configured.
+            if !f.embedCerts || *file == "" {
+                return nil
+            }
+            value = *file
+        }
+        *file, *data = "", nil
+        switch {
+        case value == "":
+        case f.embedCerts:
+            content, err := ioutil.ReadFile(value)
+            if err != nil {
+                return fmt.Errorf("failed to read %s: %v", flag, err)
+            }
+            *data = content
+        default:
+            // Store an absolute path so the config works from any directory.
+            abs, err := filepath.Abs(value)
+            if err != nil {
+                return err
+            }
+            *file = abs
+        }
+        return nil
+    }
+    if err := setFile("certificate-authority", f.certificateAuthority, &ctx.CertificateAuthority, &ctx.CertificateAuthorityData); err != nil {
+        return err
+    }
+    if err := setFile("client-certificate", f.clientCertificate, &ctx.ClientCertificate, &ctx.ClientCertificateData); err != nil {
+        return err
+    }
+    if err := setFile("client-key", f.clientKey, &ctx.ClientKey, &ctx.ClientKeyData); err != nil {
+        return err
+    }
+    if changed("insecure-skip-tls-verify") {
+        ctx.InsecureSkipTLSVerify = f.insecureSkipTLSVerify
+    }
+    if changed("proxy-url") {
+        ctx.ProxyURL = f.proxyURL
+    }
+    if changed("server-name") {
+        ctx.ServerName = f.serverName
+    }
+
+    if _, err := dependencytrack.NewTransport(transportConfig(ctx)); err != nil {
+        return fmt.Errorf("invalid connection settings: %w", err)
+    }
+    return nil
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+
+    "github.com/spf13/cobra"
+    "dtctl/pkg/config"
+)
+
+// credentialFlags are the token flags shared by add-context and edit-context.
+// Exactly one token source is kept per context.
+type credentialFlags struct {
+    token        string
+    tokenFile    string
+    tokenEnv     string
+    execCommand  string
+    execArgs     []string
+    execEnv      []string
+    encryptToken bool
+}
+
+// tokenSourceFlags are the flags that each select a token source.
+var tokenSourceFlags = []string{"token", "token-file", "token-env", "exec-command"}
+
+// register adds the flags to cmd. The local --token shadows the global one,
+// so on these commands it names the token to store.
+func (f *credentialFlags) register(cmd *cobra.Command) {
+    cmd.Flags().StringVar(&f.token, "token", "", "API token, stored in the config (encrypted with --encrypt-token)")
+    cmd.Flags().StringVar(&f.tokenFile, "token-file", "", "File to read the API token from")
+    cmd.Flags().StringVar(&f.tokenEnv, "token-env", "", "Environment variable to read the API token from")
+    cmd.Flags().StringVar(&f.execCommand, "exec-command", "", "Command that prints the API token on stdout")
+    cmd.Flags().StringArrayVar(&f.execArgs, "exec-arg", nil, "Argument for --exec-command (repeatable)")
+    cmd.Flags().StringArrayVar(&f.execEnv, "exec-env", nil, "KEY=VALUE environment variable for --exec-command (repeatable)")
+    cmd.Flags().BoolVar(&f.encryptToken, "encrypt-token", false, "Encrypt the token with a passphrase (from "+config.PassphraseEnv+" or a prompt)")
+}
+
+// changed reports whether any credential flag was given.
+func (f *credentialFlags) changed(cmd *cobra.Command) bool {
+    for _, name := range append(tokenSourceFlags, "exec-arg", "exec-env", "encrypt-token") {
+        if cmd.Flags().Changed(name) {
+            return true
+        }
+    }
+    return false
+}
+
+// apply stores the token source given by the flags in ctx, replacing the
+// previous one. With required set, ctx must end up with a token source.
+func (f *credentialFlags) apply(cmd *cobra.Command, ctx *config.Context, required bool) error {
+    changed := cmd.Flags().Changed
+    var given []string
+    for _, name := range tokenSourceFlags {
+        if changed(name) {
+            given = append(given, "--"+name)
+        }
+    }
+    if len(given) > 1 {
+        return fmt.Errorf("only one of --token, --token-file, --token-env and --exec-command may be given, got %s", strings.Join(given, " and "))
+    }
+    if f.encryptToken && len(given) == 1 && !changed("token") {
+        return fmt.Errorf("--encrypt-token only applies to --token")
+    }
+    if (changed("exec-arg") || changed("exec-env")) && !changed("exec-command") && ctx.Exec == nil {
+        return fmt.Errorf("--exec-arg and --exec-env need --exec-command")
+    }
+
+    switch {
+    case changed("token"):
+        ctx.ClearToken()
+        ctx.Token = f.token
+    case changed("token-file"):
+        abs, err := filepath.Abs(f.tokenFile)
+        if err != nil {
+            return err
+        }
+        if _, err := os.Stat(abs); err != nil {
+            return fmt.Errorf("invalid --token-file: %v", err)
+        }
+        ctx.ClearToken()
+        ctx.TokenFile = abs
+    case changed("token-env"):
+        ctx.ClearToken()
+        ctx.TokenEnv = f.tokenEnv
+    case changed("exec-command"):
+        ctx.ClearToken()
+        ctx.Exec = &config.ExecCredential{Command: f.execCommand}
+    }
+    if ctx.Exec != nil {
+        if changed("exec-arg") {
+            ctx.Exec.Args = f.execArgs
+        }
+        if changed("exec-env") {
+            for _, kv := range f.execEnv {
+                if !strings.Contains(kv, "=") {
+                    return fmt.Errorf("invalid --exec-env %q: expected KEY=VALUE", kv)
+                }
+            }
+            ctx.Exec.Env = f.execEnv
+        }
+    }
+
+    // --encrypt-token on its own encrypts the token already stored.
+    if f.encryptToken {
+        if ctx.Token == "" {
+            return fmt.Errorf("--encrypt-token needs a token stored in the config")
+        }
+        passphrase, err := config.GetPassphrase(ctx.Name)
+        if err != nil {
+            return err
+        }
+        encrypted, err := config.EncryptToken(ctx.Token, passphrase)
+        if err != nil {
+            return err
+        }
+        ctx.ClearToken()
+        ctx.EncryptedToken = encrypted
+    }
+
+    if required && ctx.TokenSource() == "" {
+        return fmt.Errorf("one of --token, --token-file, --token-env or --exec-command is required")
+    }
+    return nil
+}
+
+// promptPassphrase reads a passphrase from the terminal. Echo is turned off
+// where stty is available.
+func promptPassphrase(contextName string) (string, error) {
+    if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+        return "", fmt.Errorf("a passphrase is needed for the token of context '%s'; set %s", contextName, config.PassphraseEnv)
+    }
+    fmt.Fprintf(os.Stderr, "Passphrase for context '%s': ", contextName)
+    if stty("-echo") == nil {
+        defer func() {
+            stty("echo")
+            fmt.Fprintln(os.Stderr)
+        }()
+    }
+    line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+    if err != nil && line == "" {
+        return "", fmt.Errorf("failed to read passphrase for the token of context '%s' (%v); set %s", contextName, err, config.PassphraseEnv)
+    }
+    return strings.TrimRight(line, "\r\n"), nil
+}
+
+func stty(arg string) error {
+    cmd := exec.Command("stty", arg)
+    cmd.Stdin = os.Stdin
+    return cmd.Run()
+}
+
+func init() {
+    config.PassphrasePrompt = promptPassphrase
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+    "fmt"
+
+    "github.com/spf13/cobra"
+    "dtctl/pkg/config"
+)
+
+func init() {
+    configCmd.AddCommand(currentContextCmd)
+}
+
+var currentContextCmd = &cobra.Command{
+    Use:   "current-context",
+    Short: "Display the context commands run against",
+    Long:  "Display the context commands run against: the one given by --context or DTCTL_CONTEXT, or else the current context of the config file.",
+    Args:  cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        name, err := config.CurrentContextName(config.Overrides{Context: contextName})
+        if err != nil {
+            return err
+        }
+        fmt.Fprintln(cmd.OutOrStdout(), name)
+        return nil
+    },
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+    "fmt"
+
+    "github.com/spf13/cobra"
+    "dtctl/pkg/config"
+)
+
+func init() {
+    configCmd.AddCommand(deleteContextCmd)
+}
+
+var deleteContextCmd = &cobra.Command{
+    Use:   "delete-context NAME",
+    Short: "Delete a context",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        name := args[0]
+        wasCurrent, err := config.DeleteContext(name)
+        if err != nil {
+            return err
+        }
+        fmt.Fprintf(cmd.OutOrStdout(), "Context '%s' deleted successfully.\n", name)
+        if wasCurrent {
+            fmt.Fprintln(cmd.ErrOrStderr(), "Warning: the current context was deleted; use 'dtctl config use-context' to select another.")
+        }
+        return nil
+    },
+}
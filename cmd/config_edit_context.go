@@ -8,14 +8,22 @@ import (
 )
 
 var (
-    editURL   string
-    editToken string
+    editURL          string
+    editRetries      int
+    editRetryUpdates bool
+    editConnection   connectionFlags
+    editCredentials  credentialFlags
+    editVerify       bool
 )
 
 func init() {
     configCmd.AddCommand(editContextCmd)
     editContextCmd.Flags().StringVar(&editURL, "url", "", "New Dependency-Track server URL")
-    editContextCmd.Flags().StringVar(&editToken, "token", "", "New API token")
+    editCredentials.register(editContextCmd)
+    editContextCmd.Flags().IntVar(&editRetries, "context-max-retries", 0, "New number of retries for transient failures (negative to reset to the client default)")
+    editContextCmd.Flags().BoolVar(&editRetryUpdates, "retry-updates", false, "Also retry the POST requests made by set commands")
+    editConnection.register(editContextCmd)
+    editContextCmd.Flags().BoolVar(&editVerify, "verify", false, "Check the server and token with 'config test-context' before saving")
 }
 
 var editContextCmd = &cobra.Command{
@@ -24,8 +32,10 @@ var editContextCmd = &cobra.Command{
     Args:  cobra.ExactArgs(1),
     RunE: func(cmd *cobra.Command, args []string) error {
         name := args[0]
-        if editURL == "" && editToken == "" {
-            return fmt.Errorf("no changes specified; use --url and/or --token to modify the context")
+        retriesChanged := cmd.Flags().Changed("context-max-retries")
+        retryUpdatesChanged := cmd.Flags().Changed("retry-updates")
+        if editURL == "" && !retriesChanged && !retryUpdatesChanged && !editCredentials.changed(cmd) && !editConnection.changed(cmd) {
+            return fmt.Errorf("no changes specified; use --url, --context-max-retries, --retry-updates or the token, TLS and proxy flags to modify the context")
         }
 
         ctx, err := config.GetContext(name)
@@ -36,8 +46,27 @@ var editContextCmd = &cobra.Command{
         if editURL != "" {
             ctx.URL = editURL
         }
-        if editToken != "" {
-            ctx.Token = editToken
+        if retriesChanged {
+            if editRetries < 0 {
+                ctx.MaxRetries = nil
+            } else {
+                ctx.MaxRetries = &editRetries
+            }
+        }
+        if retryUpdatesChanged {
+            ctx.RetryUpdates = editRetryUpdates
+        }
+        if err := editCredentials.apply(cmd, ctx, false); err != nil {
+            return err
+        }
+        if err := editConnection.apply(cmd, ctx); err != nil {
+            return err
+        }
+
+        if editVerify {
+            if err := verifyContext(cmd, *ctx); err != nil {
+                return err
+            }
         }
 
         // Update the context in the configuration
@@ -46,7 +75,7 @@ var editContextCmd = &cobra.Command{
             return err
         }
 
-        fmt.Printf("Context '%s' updated successfully.\n", name)
+        fmt.Fprintf(cmd.OutOrStdout(), "Context '%s' updated successfully.\n", name)
         return nil
     },
 }
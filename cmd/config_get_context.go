@@ -1,16 +1,19 @@
 package cmd
 
 import (
-    "encoding/json"
     "fmt"
+    neturl "net/url"
+    "strings"
 
     "github.com/spf13/cobra"
     "dtctl/pkg/config"
-    "gopkg.in/yaml.v2"
 )
 
 var outputFormat string
 
+// redacted replaces secrets in displayed contexts.
+const redacted = "REDACTED"
+
 func init() {
     configCmd.AddCommand(getContextCmd)
     getContextCmd.Flags().StringVarP(&outputFormat, "output", "o", "yaml", "Output format (yaml or json)")
@@ -27,21 +30,66 @@ var getContextCmd = &cobra.Command{
             return err
         }
         switch outputFormat {
-        case "yaml":
-            data, err := yaml.Marshal(ctx)
-            if err != nil {
-                return err
-            }
-            fmt.Println(string(data))
-        case "json":
-            data, err := json.MarshalIndent(ctx, "", "  ")
-            if err != nil {
-                return err
-            }
-            fmt.Println(string(data))
+        case "yaml", "json":
+            return writeStructured(cmd.OutOrStdout(), outputFormat, redactContext(*ctx))
         default:
             return fmt.Errorf("unsupported output format: %s", outputFormat)
         }
-        return nil
     },
 }
+
+// redactedContext is a context as displayed: its fields shadow the secrets
+// of the embedded context, which are shown as REDACTED when set.
+type redactedContext struct {
+    config.Context
+    Token          string `json:"token,omitempty"`
+    EncryptedToken string `json:"encrypted_token,omitempty"`
+    ClientKeyData  string `json:"client_key_data,omitempty"`
+    // Exec keeps the command but redacts its arguments and the values of
+    // its environment, which may hold credentials.
+    Exec *config.ExecCredential `json:"exec,omitempty"`
+    // ProxyURL has any user:password@ replaced.
+    ProxyURL string `json:"proxy_url,omitempty"`
+}
+
+func redactContext(ctx config.Context) redactedContext {
+    r := redactedContext{Context: ctx}
+    if ctx.Token != "" {
+        r.Token = redacted
+    }
+    if ctx.EncryptedToken != "" {
+        r.EncryptedToken = redacted
+    }
+    if len(ctx.ClientKeyData) > 0 {
+        r.ClientKeyData = redacted
+    }
+    r.ProxyURL = redactUserinfo(ctx.ProxyURL)
+    if ctx.Exec != nil {
+        exec := config.ExecCredential{Command: ctx.Exec.Command}
+        for range ctx.Exec.Args {
+            exec.Args = append(exec.Args, redacted)
+        }
+        for _, kv := range ctx.Exec.Env {
+            key := strings.SplitN(kv, "=", 2)[0]
+            exec.Env = append(exec.Env, key+"="+redacted)
+        }
+        r.Exec = &exec
+    }
+    return r
+}
+
+// redactUserinfo replaces the user and password of rawURL, if it has any.
+// A URL that does not parse is redacted as a whole.
+func redactUserinfo(rawURL string) string {
+    if !strings.Contains(rawURL, "@") {
+        return rawURL
+    }
+    u, err := neturl.Parse(rawURL)
+    if err != nil {
+        return redacted
+    }
+    if u.User != nil {
+        u.User = neturl.User(redacted)
+    }
+    return u.String()
+}
@@ -19,13 +19,13 @@ var getContextsCmd = &cobra.Command{
         if err != nil {
             return err
         }
-        fmt.Println("Available contexts:")
+        fmt.Fprintln(cmd.OutOrStdout(), "Available contexts:")
         for _, ctx := range cfg.Contexts {
             current := " "
             if ctx.Name == cfg.CurrentContext {
                 current = "*"
             }
-            fmt.Printf("%s %s\n", current, ctx.Name)
+            fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", current, ctx.Name)
         }
         return nil
     },
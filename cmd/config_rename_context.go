@@ -0,0 +1,25 @@
+package cmd
+
+import (
+    "fmt"
+
+    "github.com/spf13/cobra"
+    "dtctl/pkg/config"
+)
+
+func init() {
+    configCmd.AddCommand(renameContextCmd)
+}
+
+var renameContextCmd = &cobra.Command{
+    Use:   "rename-context OLD_NAME NEW_NAME",
+    Short: "Rename a context",
+    Args:  cobra.ExactArgs(2),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if err := config.RenameContext(args[0], args[1]); err != nil {
+            return err
+        }
+        fmt.Fprintf(cmd.OutOrStdout(), "Context '%s' renamed to '%s'.\n", args[0], args[1])
+        return nil
+    },
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+    "fmt"
+
+    "github.com/spf13/cobra"
+    "dtctl/pkg/config"
+)
+
+func init() {
+    configCmd.AddCommand(configSetCmd)
+    configCmd.AddCommand(configUnsetCmd)
+}
+
+const propertyPathHelp = `PROPERTY_PATH is current_context or contexts.NAME.PROPERTY, where PROPERTY is a
+key of the context as shown by 'dtctl config get-context -o json', e.g. url,
+max_retries, token_env or exec.command.`
+
+var configSetCmd = &cobra.Command{
+    Use:   "set PROPERTY_PATH VALUE",
+    Short: "Set a single value in the config file",
+    Long: `Set a single value in the config file.
+
+` + propertyPathHelp + `
+
+Booleans are true or false, *_data values are base64 and lists such as
+exec.args are comma-separated. Unlike add-context and edit-context, set does
+not replace other token sources; unset them first.`,
+    Example: `  dtctl config set contexts.prod.url https://dt.example.com
+  dtctl config set contexts.prod.max_retries 5
+  dtctl config set current_context prod`,
+    Args: cobra.ExactArgs(2),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if err := config.SetProperty(args[0], args[1]); err != nil {
+            return err
+        }
+        fmt.Fprintf(cmd.OutOrStdout(), "Property '%s' set.\n", args[0])
+        return nil
+    },
+}
+
+var configUnsetCmd = &cobra.Command{
+    Use:   "unset PROPERTY_PATH",
+    Short: "Unset a single value in the config file",
+    Long: `Unset a single value in the config file.
+
+` + propertyPathHelp,
+    Example: `  dtctl config unset contexts.prod.proxy_url
+  dtctl config unset contexts.prod.exec`,
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if err := config.UnsetProperty(args[0]); err != nil {
+            return err
+        }
+        fmt.Fprintf(cmd.OutOrStdout(), "Property '%s' unset.\n", args[0])
+        return nil
+    },
+}
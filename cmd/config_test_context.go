@@ -0,0 +1,188 @@
+package cmd
+
+import (
+    "context"
+    "crypto/tls"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "strings"
+    "text/tabwriter"
+
+    "github.com/spf13/cobra"
+    "dtctl/pkg/config"
+    "dtctl/pkg/dependencytrack"
+)
+
+func init() {
+    configCmd.AddCommand(testContextCmd)
+}
+
+var testContextCmd = &cobra.Command{
+    Use:   "test-context [NAME]",
+    Short: "Check the connection and token of a context",
+    Long: `Check the connection and token of a context, by default the one commands run
+against. Reports the server version, the TLS connection, the team the token
+belongs to and whether it has the permissions dtctl commands need.
+
+Fails if the server cannot be reached or rejects the token. Missing
+permissions are reported but do not fail the check.`,
+    Args: cobra.MaximumNArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if len(args) == 1 {
+            if cmd.Flags().Changed("context") && contextName != args[0] {
+                return fmt.Errorf("give the context either as NAME or with --context, not both")
+            }
+            contextName = args[0]
+        }
+        client, err := newClient()
+        if err != nil {
+            return err
+        }
+        name, err := config.CurrentContextName(config.Overrides{Context: contextName})
+        if err != nil && err != config.ErrNoContext {
+            return err
+        }
+        return checkContext(cmd.Context(), cmd.OutOrStdout(), name, client)
+    },
+}
+
+// commandPermissions lists the permissions each command needs.
+var commandPermissions = []struct {
+    command     string
+    permissions []string
+}{
+    {"get projects", []string{dependencytrack.PermissionViewPortfolio}},
+    {"get components", []string{dependencytrack.PermissionViewPortfolio}},
+    {"get policies", []string{dependencytrack.PermissionPolicyManagement}},
+    {"get hashpolicycondition", []string{dependencytrack.PermissionPolicyManagement, dependencytrack.PermissionViewPortfolio}},
+    {"eval policy", []string{dependencytrack.PermissionPolicyManagement, dependencytrack.PermissionViewPortfolio, dependencytrack.PermissionViewVulnerability}},
+    {"set component", []string{dependencytrack.PermissionPortfolioManagement, dependencytrack.PermissionViewPortfolio}},
+    {"set hashpolicycondition", []string{dependencytrack.PermissionPolicyManagement}},
+}
+
+// checkContext reports on the server and token behind client. It fails when
+// the server is unreachable or rejects the token.
+func checkContext(ctx context.Context, out io.Writer, name string, client dependencytrack.API) error {
+    w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+    defer w.Flush()
+
+    if name != "" {
+        fmt.Fprintf(w, "Context:\t%s\n", name)
+    }
+    if c, ok := client.(*dependencytrack.Client); ok {
+        fmt.Fprintf(w, "Server:\t%s\n", c.BaseURL)
+    }
+
+    about, err := client.GetVersionContext(ctx)
+    if err != nil {
+        fmt.Fprintf(w, "Reachable:\tno\n")
+        return fmt.Errorf("server is not reachable: %w", err)
+    }
+    fmt.Fprintf(w, "Reachable:\tyes\n")
+    fmt.Fprintf(w, "Version:\t%s\n", strings.TrimSpace(about.Application+" "+about.Version))
+    fmt.Fprintf(w, "TLS:\t%s\n", describeTLS(about.TLS))
+
+    team, err := client.GetCurrentTeamContext(ctx)
+    var apiErr *dependencytrack.APIError
+    switch {
+    case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+        // Older servers cannot tell the team of an API key; listing a
+        // single project at least shows whether the token is accepted.
+        fmt.Fprintf(w, "Team:\tunknown, the server does not report the team of API keys\n")
+        err = client.WalkProjectsContext(ctx, func([]dependencytrack.Project) error { return dependencytrack.ErrStopWalk })
+        if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+            err = nil
+        }
+        if err != nil {
+            fmt.Fprintf(w, "Token:\trejected\n")
+            return fmt.Errorf("token check failed: %w", err)
+        }
+        fmt.Fprintf(w, "Token:\taccepted\n")
+        fmt.Fprintf(w, "Permissions:\tunknown\n")
+        return nil
+    case err != nil:
+        fmt.Fprintf(w, "Token:\trejected\n")
+        return fmt.Errorf("token check failed: %w", err)
+    }
+    fmt.Fprintf(w, "Token:\taccepted\n")
+    fmt.Fprintf(w, "Team:\t%s\n", team.Name)
+
+    fmt.Fprintf(w, "Permissions:\n")
+    w.Flush()
+
+    w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+    for _, c := range commandPermissions {
+        var missing []string
+        for _, p := range c.permissions {
+            if !team.HasPermission(p) {
+                missing = append(missing, p)
+            }
+        }
+        status := "ok"
+        if len(missing) > 0 {
+            status = "missing " + strings.Join(missing, ", ")
+        }
+        fmt.Fprintf(w, "  %s\t%s\t%s\n", c.command, strings.Join(c.permissions, ", "), status)
+    }
+    return w.Flush()
+}
+
+// describeTLS summarizes a TLS connection and the server certificate.
+func describeTLS(state *tls.ConnectionState) string {
+    if state == nil {
+        return "none (plain HTTP)"
+    }
+    desc := tlsVersionName(state.Version) + ", " + tls.CipherSuiteName(state.CipherSuite)
+    if len(state.PeerCertificates) > 0 {
+        cert := state.PeerCertificates[0]
+        subject := cert.Subject.CommonName
+        if subject == "" && len(cert.DNSNames) > 0 {
+            subject = cert.DNSNames[0]
+        }
+        issuer := cert.Issuer.CommonName
+        if issuer == "" {
+            issuer = cert.Issuer.String()
+        }
+        desc += fmt.Sprintf(", certificate %s issued by %s, valid until %s",
+            subject, issuer, cert.NotAfter.UTC().Format("2006-01-02"))
+    }
+    if len(state.VerifiedChains) == 0 {
+        desc += ", not verified"
+    }
+    return desc
+}
+
+func tlsVersionName(version uint16) string {
+    switch version {
+    case tls.VersionTLS10:
+        return "TLS 1.0"
+    case tls.VersionTLS11:
+        return "TLS 1.1"
+    case tls.VersionTLS12:
+        return "TLS 1.2"
+    case tls.VersionTLS13:
+        return "TLS 1.3"
+    }
+    return fmt.Sprintf("TLS 0x%04x", version)
+}
+
+// verifyContext runs checkContext against ctx before it is saved.
+func verifyContext(cmd *cobra.Command, ctx config.Context) error {
+    token, err := ctx.ResolveToken()
+    if err != nil {
+        return fmt.Errorf("context '%s' not saved: %w", ctx.Name, err)
+    }
+    ctx.ClearToken()
+    ctx.Token = token
+    client, err := contextClient(&ctx)
+    if err != nil {
+        return err
+    }
+    traceClient(client)
+    if err := checkContext(cmd.Context(), cmd.OutOrStdout(), ctx.Name, client); err != nil {
+        return fmt.Errorf("context '%s' not saved: %w", ctx.Name, err)
+    }
+    return nil
+}
@@ -20,7 +20,7 @@ var useContextCmd = &cobra.Command{
         if err := config.UseContext(name); err != nil {
             return err
         }
-        fmt.Printf("Switched to context '%s'.\n", name)
+        fmt.Fprintf(cmd.OutOrStdout(), "Switched to context '%s'.\n", name)
         return nil
     },
 }
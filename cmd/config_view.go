@@ -0,0 +1,54 @@
+package cmd
+
+import (
+    "fmt"
+
+    "github.com/spf13/cobra"
+    "dtctl/pkg/config"
+)
+
+var (
+    viewOutput string
+    viewMinify bool
+)
+
+func init() {
+    configCmd.AddCommand(viewCmd)
+    viewCmd.Flags().StringVarP(&viewOutput, "output", "o", "yaml", "Output format (yaml or json)")
+    viewCmd.Flags().BoolVar(&viewMinify, "minify", false, "Only show the current context")
+}
+
+var viewCmd = &cobra.Command{
+    Use:   "view",
+    Short: "Display the effective configuration",
+    Long: `Display the configuration as commands see it, with secrets redacted.
+
+The --context, --server and --token flags and the DTCTL_CONTEXT, DTCTL_URL and
+DTCTL_TOKEN environment variables are applied to it.`,
+    Args: cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        if viewOutput != "yaml" && viewOutput != "json" {
+            return fmt.Errorf("unsupported output format: %s", viewOutput)
+        }
+        cfg, err := config.EffectiveConfig(config.Overrides{Context: contextName, Server: serverURL, Token: apiToken})
+        if err != nil {
+            return err
+        }
+
+        view := redactedConfig{APIVersion: cfg.APIVersion, CurrentContext: cfg.CurrentContext, Contexts: []redactedContext{}}
+        for _, ctx := range cfg.Contexts {
+            if viewMinify && ctx.Name != cfg.CurrentContext {
+                continue
+            }
+            view.Contexts = append(view.Contexts, redactContext(ctx))
+        }
+        return writeStructured(cmd.OutOrStdout(), viewOutput, view)
+    },
+}
+
+// redactedConfig is a config as displayed by view.
+type redactedConfig struct {
+    APIVersion     string            `json:"apiVersion"`
+    CurrentContext string            `json:"current_context"`
+    Contexts       []redactedContext `json:"contexts"`
+}
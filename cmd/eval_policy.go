@@ -1,15 +1,15 @@
 package cmd
 
 import (
-    "encoding/json"
+    "context"
     "fmt"
-    "os"
+    "io"
     "strings"
     "text/tabwriter"
 
     "github.com/spf13/cobra"
-    "dtctl/pkg/config"
     "dtctl/pkg/dependencytrack"
+    policyeval "dtctl/pkg/policy"
 )
 
 var evalPolicyUUID string
@@ -17,139 +17,170 @@ var evalPolicyUUID string
 var evalPolicyCmd = &cobra.Command{
     Use:   "policy",
     Short: "Evaluate if a policy is violated",
+    Long: `Evaluate if a policy is violated by the components of the projects it applies to.
+
+Every condition of the policy is evaluated. With the policy operator ANY, one
+matching condition is a violation; with ALL, every condition must match. The
+conditions that matched are listed for each component. Components for which
+a condition could not be evaluated are reported as UNKNOWN.
+
+The subjects AGE, COMPONENT_HASH, COORDINATES, CPE, CWE, EPSS, LICENSE,
+LICENSE_GROUP, PACKAGE_URL, SEVERITY, SWID_TAGID, VERSION and VULNERABILITY_ID
+are evaluated. MATCHES and NO_MATCH take regular expressions that must match
+the whole value, and versions are compared by the rules of the component's
+ecosystem, e.g. Maven or npm. Conditions on vulnerabilities ignore
+suppressed findings.
+
+The policy is evaluated against the projects in its scope: every project for
+a global policy, otherwise the projects it is assigned to, with their children
+if it includes children, and the projects with one of its tags. Policies for
+the latest project version only skip older versions. With -v the resolved
+scope is logged to stderr.`,
     RunE:  evalPolicy,
 }
 
 func init() {
     evalPolicyCmd.Flags().StringVar(&evalPolicyUUID, "uuid", "", "UUID of the policy (required)")
     evalPolicyCmd.MarkFlagRequired("uuid")
+    addFanOutFlags(evalPolicyCmd)
     evalCmd.AddCommand(evalPolicyCmd)
 }
 
 func evalPolicy(cmd *cobra.Command, args []string) error {
-    // Retrieve config
-    cfg, err := config.GetConfig()
-    if err != nil {
-        return err
-    }
-    if cfg.CurrentContext == "" {
-        return fmt.Errorf("no current context is set; use 'dtctl config use-context' to set one")
-    }
-    ctx, err := config.GetCurrentContext()
+    // Initialize client
+    client, err := newClient()
     if err != nil {
         return err
     }
-
-    // Initialize client
-    client := dependencytrack.NewClient(ctx.URL, ctx.Token)
+    ctx := cmd.Context()
 
     // Fetch the policy
-    policy, err := client.GetPolicyByUUID(evalPolicyUUID)
+    policy, err := client.GetPolicyByUUIDContext(ctx, evalPolicyUUID)
     if err != nil {
-        return fmt.Errorf("failed to get policy: %v", err)
+        return fmt.Errorf("failed to get policy: %w", err)
     }
 
-    policyName, _ := policy["name"].(string)
-    policyConditions, ok := policy["policyConditions"].([]interface{})
-    if !ok || len(policyConditions) == 0 {
-        fmt.Println("No policy conditions found. No violation.")
+    if len(policy.PolicyConditions) == 0 {
+        fmt.Fprintln(cmd.OutOrStdout(), "No policy conditions found. No violation.")
         return nil
     }
-
-    condition := policyConditions[0].(map[string]interface{})
-    operator, _ := condition["operator"].(string)  // e.g., "IS" or "IS_NOT"
-    valueStr, _ := condition["value"].(string)
-
-    // Parse value as JSON to extract the hash
-    var valObj map[string]string
-    if err := json.Unmarshal([]byte(valueStr), &valObj); err != nil {
-        return fmt.Errorf("failed to parse condition value: %v", err)
+    if err := policyeval.ResolveLicenseGroups(ctx, client, policy); err != nil {
+        return err
     }
+    needsFindings := policyeval.NeedsFindings(*policy)
 
-    algorithmValue := valObj["value"]
-
-    projects, ok := policy["projects"].([]interface{})
-    if !ok || len(projects) == 0 {
-        fmt.Println("No projects associated with the policy. No violation.")
+    scope, err := policyeval.Scope(ctx, client, *policy)
+    if err != nil {
+        return err
+    }
+    if verbosity > 0 && logger != nil {
+        for _, project := range scope {
+            logger.Log("policy scope", "project", project.Name, "version", project.Version, "uuid", project.UUID, "reason", project.Reason)
+        }
+    }
+    if len(scope) == 0 {
+        fmt.Fprintln(cmd.OutOrStdout(), "No projects in the scope of the policy. No violation.")
         return nil
     }
+    targets := make([]dependencytrack.Project, len(scope))
+    for i, project := range scope {
+        targets[i] = project.Project
+    }
 
-    // Prepare a data structure to hold results for tabulation
-    // Each record: Policy, Component, Violation State
-    var results [][]string
-
-    for _, p := range projects {
-        proj := p.(map[string]interface{})
-        projectUUID, _ := proj["uuid"].(string)
+    // Each record: Policy, Component, Violation State, Matched Conditions.
+    // Every project fills its own slot so the table keeps project order.
+    perProject := make([][][]string, len(targets))
+    perProjectWarnings := make([][]string, len(targets))
 
+    // walkErr is set when the walk was interrupted or --keep-going skipped
+    // failed projects; results for the evaluated projects are still printed.
+    walkErr := forEachProject(ctx, targets, nil, func(ctx context.Context, i int, project dependencytrack.Project) error {
         // Get components for this project
-        components, err := client.GetComponentsByProjectUUID(projectUUID)
+        components, err := client.GetComponentsByProjectUUIDContext(ctx, project.UUID)
         if err != nil {
-            return fmt.Errorf("failed to get components for project %s: %v", projectUUID, err)
+            return fmt.Errorf("failed to get components for project %s: %w", project.UUID, err)
+        }
+        // Only policies on vulnerabilities need the findings; suppressed
+        // ones never count.
+        var findings []dependencytrack.Finding
+        if needsFindings {
+            findings, err = client.GetProjectFindingsContext(ctx, project.UUID, false)
+            if err != nil {
+                return fmt.Errorf("failed to get findings for project %s: %w", project.UUID, err)
+            }
         }
 
-        // Evaluate condition according to the corrected logic
+        var rows [][]string
         for _, comp := range components {
-            compHash := strings.ToLower(strings.TrimSpace(comp.Sha256))
-            algoValLower := strings.ToLower(strings.TrimSpace(algorithmValue))
-
-            // Default to no violation unless conditions met
-            violation := false
-
-            switch operator {
-            case "IS":
-                // IS:
-                // If match => violation
-                // If no match => no violation
-                if compHash == algoValLower {
-                    violation = true
-                }
-            case "IS_NOT":
-                // IS_NOT:
-                // If match => no violation
-                // If no match => violation
-                if compHash != algoValLower {
-                    violation = true
-                }
-            default:
-                // If we encounter an operator not handled, treat as no violation
-                // but print a warning
-                fmt.Printf("Warning: Operator %s not handled, defaulting to no violation.\n", operator)
+            target := policyeval.Target{Component: comp}
+            if needsFindings {
+                target.Findings = dependencytrack.ComponentFindings(findings, comp.UUID)
+                target.FindingsLoaded = true
             }
-
-            violationState := "NOT VIOLATED"
-            if violation {
-                violationState = "VIOLATED"
+            result := policyeval.Evaluate(*policy, target)
+            var matched []string
+            for _, c := range result.Matched() {
+                matched = append(matched, policyeval.Describe(c))
             }
+            rows = append(rows, []string{policy.Name, comp.Name, string(result.State), strings.Join(matched, "; ")})
+            for _, err := range result.Errors() {
+                perProjectWarnings[i] = append(perProjectWarnings[i], err.Error())
+            }
+        }
+        perProject[i] = rows
+        return nil
+    })
+    if walkErr != nil && !keepGoing && ctx.Err() == nil {
+        return walkErr
+    }
 
-            // Add the result line for each component
-            results = append(results, []string{policyName, comp.Name, violationState})
+    // Conditions that could not be evaluated are reported once each.
+    seen := map[string]bool{}
+    for _, warnings := range perProjectWarnings {
+        for _, w := range warnings {
+            if !seen[w] {
+                seen[w] = true
+                fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", w)
+            }
         }
     }
 
+    var results [][]string
+    for _, rows := range perProject {
+        results = append(results, rows...)
+    }
+
     if len(results) == 0 {
+        if walkErr != nil {
+            return walkErr
+        }
         // If no components or nothing processed means no violation lines
-        fmt.Println("No violation detected.")
+        fmt.Fprintln(cmd.OutOrStdout(), "No violation detected.")
         return nil
     }
 
-    printTabulatedResults(results)
+    printTabulatedResults(cmd.OutOrStdout(), results)
 
-    return nil
+    return walkErr
 }
 
-func printTabulatedResults(results [][]string) {
+func printTabulatedResults(out io.Writer, results [][]string) {
     // Initialize a tabwriter
-    w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
 
     // Print headers
-    fmt.Fprintln(w, "Policy\tComponent\tViolation State")
+    fmt.Fprintln(w, "Policy\tComponent\tViolation State\tMatched Conditions")
 
     // Print a separator line (optional)
-    fmt.Fprintln(w, "------\t---------\t--------------")
+    fmt.Fprintln(w, "------\t---------\t--------------\t------------------")
 
     for _, row := range results {
-        fmt.Fprintf(w, "%s\t%s\t%s\n", row[0], row[1], row[2])
+        if row[3] == "" {
+            // Without a trailing cell the state column is not padded.
+            fmt.Fprintf(w, "%s\t%s\t%s\n", row[0], row[1], row[2])
+            continue
+        }
+        fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row[0], row[1], row[2], row[3])
     }
 
     w.Flush()
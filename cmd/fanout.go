@@ -0,0 +1,208 @@
+package cmd
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "sync"
+    "sync/atomic"
+
+    "github.com/spf13/cobra"
+    "dtctl/pkg/dependencytrack"
+)
+
+var (
+    // concurrency is the number of projects processed in parallel.
+    concurrency int
+    // keepGoing makes a per-project walk continue past failed projects.
+    keepGoing bool
+)
+
+// addFanOutFlags registers the flags controlling forEachProject on cmd.
+func addFanOutFlags(cmd *cobra.Command) {
+    cmd.Flags().IntVar(&concurrency, "concurrency", 4, "Number of projects to process in parallel")
+    cmd.Flags().BoolVar(&keepGoing, "keep-going", false, "Continue with the remaining projects when one fails and report the failures at the end")
+}
+
+// projectError records the failure of a single project in a walk.
+type projectError struct {
+    Project dependencytrack.Project
+    Err     error
+}
+
+// projectErrors is returned by forEachProject with --keep-going when at least
+// one project failed.
+type projectErrors struct {
+    total  int
+    errors []projectError
+}
+
+func (e *projectErrors) Error() string {
+    var b strings.Builder
+    fmt.Fprintf(&b, "failed for %d of %d projects:", len(e.errors), e.total)
+    for _, pe := range e.errors {
+        fmt.Fprintf(&b, "\n  %s (%s): %v", pe.Project.Name, pe.Project.UUID, pe.Err)
+    }
+    return b.String()
+}
+
+// Unwrap returns the most severe failure, the first of them in project
+// order, so the exit code does not depend on which project failed first.
+func (e *projectErrors) Unwrap() error {
+    worst := e.errors[0].Err
+    for _, pe := range e.errors[1:] {
+        if errorSeverity(pe.Err) > errorSeverity(worst) {
+            worst = pe.Err
+        }
+    }
+    return worst
+}
+
+// errorSeverity ranks the failures of a project: authentication failures,
+// which fail every project alike, above server errors, conflicts, missing
+// resources and anything else.
+func errorSeverity(err error) int {
+    var apiErr *dependencytrack.APIError
+    if !errors.As(err, &apiErr) {
+        return 0
+    }
+    switch {
+    case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+        return 4
+    case apiErr.StatusCode >= 500:
+        return 3
+    case apiErr.StatusCode == http.StatusConflict:
+        return 2
+    case apiErr.StatusCode == http.StatusNotFound:
+        return 1
+    }
+    return 0
+}
+
+// forEachProject calls fn for every project using up to --concurrency
+// workers. fn receives the project's index so it can store its result in a
+// slot of its own, which keeps output in project order however the workers
+// are scheduled. enough, when not nil, is consulted before each project is
+// started and stops the walk early once it returns true.
+//
+// By default the first failure cancels the other workers and is returned as
+// is. With --keep-going every project is attempted and the failures are
+// returned together as a *projectErrors. If ctx ends first, the error
+// reports how many projects completed. In the last two cases the results of
+// the projects that succeeded are valid and should still be shown.
+func forEachProject(ctx context.Context, projects []dependencytrack.Project, enough func() bool, fn func(ctx context.Context, i int, project dependencytrack.Project) error) error {
+    workers := concurrency
+    if workers < 1 {
+        workers = 1
+    }
+
+    walkCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    var (
+        mu       sync.Mutex
+        done     int
+        firstErr error
+        failures []projectError
+        wg       sync.WaitGroup
+    )
+    errs := make([]error, len(projects))
+
+    next := make(chan int)
+    for w := 0; w < workers; w++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for i := range next {
+                err := fn(walkCtx, i, projects[i])
+                mu.Lock()
+                if err == nil {
+                    done++
+                } else if walkCtx.Err() == nil {
+                    // Errors after cancellation are only the fallout of it.
+                    errs[i] = err
+                    if firstErr == nil && !keepGoing {
+                        firstErr = err
+                        cancel()
+                    }
+                }
+                mu.Unlock()
+            }
+        }()
+    }
+
+dispatch:
+    for i := range projects {
+        if enough != nil && enough() {
+            break
+        }
+        select {
+        case next <- i:
+        case <-walkCtx.Done():
+            break dispatch
+        }
+    }
+    close(next)
+    wg.Wait()
+
+    if ctx.Err() != nil {
+        return interruptedError(ctx, done, len(projects))
+    }
+    if firstErr != nil {
+        return firstErr
+    }
+    for i, err := range errs {
+        if err != nil {
+            failures = append(failures, projectError{Project: projects[i], Err: err})
+        }
+    }
+    if len(failures) > 0 {
+        return &projectErrors{total: len(projects), errors: failures}
+    }
+    return nil
+}
+
+// forEachProjectPage walks the projects with tag page by page and runs
+// forEachProject over each page, so the projects are never all held in
+// memory. The index passed to fn counts from the first project of the walk.
+// It returns the number of projects walked. Errors are those of
+// forEachProject, with the failures of --keep-going collected across pages.
+func forEachProjectPage(ctx context.Context, client dependencytrack.API, tag string, enough func() bool, fn func(ctx context.Context, i int, project dependencytrack.Project) error) (int, error) {
+    var (
+        total    int
+        done     int64
+        failures []projectError
+    )
+    err := walkProjects(ctx, client, tag, func(page []dependencytrack.Project) error {
+        if enough != nil && enough() {
+            return dependencytrack.ErrStopWalk
+        }
+        offset := total
+        total += len(page)
+        err := forEachProject(ctx, page, enough, func(ctx context.Context, i int, project dependencytrack.Project) error {
+            if err := fn(ctx, offset+i, project); err != nil {
+                return err
+            }
+            atomic.AddInt64(&done, 1)
+            return nil
+        })
+        var pe *projectErrors
+        if errors.As(err, &pe) && ctx.Err() == nil {
+            failures = append(failures, pe.errors...)
+            return nil
+        }
+        return err
+    })
+    if ctx.Err() != nil {
+        return total, interruptedError(ctx, int(atomic.LoadInt64(&done)), total)
+    }
+    if err != nil {
+        return total, err
+    }
+    if len(failures) > 0 {
+        return total, &projectErrors{total: total, errors: failures}
+    }
+    return total, nil
+}
@@ -1,25 +1,28 @@
 package cmd
 
 import (
+    "context"
     "fmt"
-    "os"
     "strings"
-    "text/tabwriter"
+    "sync"
 
     "github.com/spf13/cobra"
-    "dtctl/pkg/config"
     "dtctl/pkg/dependencytrack"
 )
 
 var (
-    componentTag string
-    showFields   string
+    componentTag   string
+    showFields     string
+    componentLimit int
 )
 
 func init() {
     getCmd.AddCommand(getComponentsCmd)
     getComponentsCmd.Flags().StringVar(&componentTag, "tag", "", "Filter components by project tag (optional)")
-    getComponentsCmd.Flags().StringVar(&showFields, "show-fields", "", "Comma-separated list of additional fields to display (available: projectname, projectuuid, sha256, sha1, md5)")
+    getComponentsCmd.Flags().StringVar(&showFields, "show-fields", "", "Comma-separated list of additional fields to display (available: projectname, projectuuid, md5, sha1, sha256, sha384, sha512, sha3_256, sha3_384, sha3_512, blake2b_256, blake2b_384, blake2b_512, blake3)")
+    getComponentsCmd.Flags().IntVar(&componentLimit, "limit", 0, "Maximum number of components to fetch (0 for all)")
+    addFanOutFlags(getComponentsCmd)
+    addOutputFlags(getComponentsCmd)
 }
 
 var getComponentsCmd = &cobra.Command{
@@ -29,131 +32,203 @@ var getComponentsCmd = &cobra.Command{
 }
 
 func getComponents(cmd *cobra.Command, args []string) error {
-    cfg, err := config.GetConfig()
+    // Reject unknown --show-fields before fetching anything.
+    list, err := componentList(nil, showFields)
     if err != nil {
         return err
     }
-    if cfg.CurrentContext == "" {
-        return fmt.Errorf("no current context is set; use 'dtctl config use-context' to set one")
-    }
-    ctx, err := config.GetCurrentContext()
+
+    client, err := newClient()
     if err != nil {
         return err
     }
-    client := dependencytrack.NewClient(ctx.URL, ctx.Token)
-
-    var projects []dependencytrack.Project
-
-    if componentTag != "" {
-        projects, err = client.GetProjectsByTag(componentTag)
-        if err != nil {
-            return err
+    ctx := cmd.Context()
+
+    // Projects are released in order as soon as they and every project
+    // before them are done, so the output keeps project order regardless of
+    // which worker finishes first. With --keep-going, streamable formats
+    // print them right away; otherwise nothing is printed until every
+    // project succeeded, so a failure leaves no partial output.
+    var (
+        mu         sync.Mutex
+        components = []dependencytrack.Component{}
+        pending    = map[int][]dependencytrack.Component{}
+        finished   = map[int]bool{}
+        fetched    = map[int]int{}
+        next       int
+        released   int
+        printErr   error
+    )
+    var items *itemWriter
+    if keepGoing && streamable() {
+        items = newItemWriter(cmd.OutOrStdout(), list)
+    }
+    release := func(i int, found []dependencytrack.Component) {
+        mu.Lock()
+        defer mu.Unlock()
+        pending[i], finished[i] = found, true
+        for ; finished[next]; next++ {
+            found := pending[next]
+            delete(pending, next)
+            delete(finished, next)
+            delete(fetched, next)
+            if componentLimit > 0 && released+len(found) > componentLimit {
+                found = found[:componentLimit-released]
+            }
+            released += len(found)
+            if items == nil {
+                components = append(components, found...)
+                continue
+            }
+            batch := make([]interface{}, len(found))
+            for j, c := range found {
+                batch[j] = c
+            }
+            if err := items.write(batch); err != nil && printErr == nil {
+                printErr = err
+            }
         }
-    } else {
-        projects, err = client.GetProjects()
-        if err != nil {
-            return err
+    }
+    // room returns how many more components project i may fetch under
+    // --limit: the limit less what project i and the projects before it
+    // fetched. Projects before i only ever fetch more, so a project that
+    // runs out of room never holds components that would be printed, and
+    // every worker stops once the limit is reached.
+    room := func(i int) int {
+        mu.Lock()
+        defer mu.Unlock()
+        n := componentLimit - released
+        for j := next; j <= i; j++ {
+            n -= fetched[j]
         }
+        return n
     }
-
-    if len(projects) == 0 {
-        fmt.Println("No projects found.")
-        return nil
+    enough := func() bool {
+        mu.Lock()
+        defer mu.Unlock()
+        n := released
+        for _, f := range fetched {
+            n += f
+        }
+        return componentLimit > 0 && n >= componentLimit
     }
 
-    // Prepare data for display
-    type ComponentInfo struct {
-        ComponentName string
-        ComponentUUID string
-        ProjectName   string
-        ProjectUUID   string
-        Sha256        string
-        Sha1          string
-        Md5           string
-    }
-    var components []ComponentInfo
-
-    for _, project := range projects {
-        projectComponents, err := client.GetComponentsByProjectUUID(project.UUID)
+    // walkErr is set when the walk was interrupted or --keep-going skipped
+    // failed projects; whatever was fetched is still printed.
+    total, walkErr := forEachProjectPage(ctx, client, componentTag, enough, func(ctx context.Context, i int, project dependencytrack.Project) error {
+        var found []dependencytrack.Component
+        err := client.WalkComponentsByProjectUUIDContext(ctx, project.UUID, func(page []dependencytrack.Component) error {
+            if componentLimit > 0 {
+                n := room(i)
+                if n <= 0 {
+                    return dependencytrack.ErrStopWalk
+                }
+                if len(page) > n {
+                    page = page[:n]
+                }
+            }
+            for _, component := range page {
+                component.Project.UUID = project.UUID
+                component.Project.Name = project.Name
+                found = append(found, component)
+            }
+            mu.Lock()
+            fetched[i] += len(page)
+            mu.Unlock()
+            return nil
+        })
         if err != nil {
+            release(i, nil)
             return err
         }
-        for _, component := range projectComponents {
-            components = append(components, ComponentInfo{
-                ComponentName: component.Name,
-                ComponentUUID: component.UUID,
-                ProjectName:   project.Name,
-                ProjectUUID:   project.UUID,
-                Sha256:        component.Sha256,
-                Sha1:          component.Sha1,
-                Md5:           component.Md5,
-            })
-        }
-    }
-
-    if len(components) == 0 {
-        fmt.Println("No components found.")
+        release(i, found)
         return nil
+    })
+    if printErr != nil {
+        return printErr
     }
-
-    // Default headers and extractors
-    headers := []string{"COMPONENT NAME", "COMPONENT UUID"}
-    extractors := []func(ComponentInfo) string{
-        func(ci ComponentInfo) string { return ci.ComponentName },
-        func(ci ComponentInfo) string { return ci.ComponentUUID },
+    if walkErr != nil && !keepGoing && ctx.Err() == nil {
+        return walkErr
     }
 
-    // Parse --show-fields flag
-    if showFields != "" {
-        fields := parseFields(showFields)
-        for _, field := range fields {
-            switch field {
-            case "projectname":
-                headers = append(headers, "PROJECT NAME")
-                extractors = append(extractors, func(ci ComponentInfo) string { return ci.ProjectName })
-            case "projectuuid":
-                headers = append(headers, "PROJECT UUID")
-                extractors = append(extractors, func(ci ComponentInfo) string { return ci.ProjectUUID })
-            case "sha256":
-                headers = append(headers, "SHA256")
-                extractors = append(extractors, func(ci ComponentInfo) string { return ci.Sha256 })
-            case "sha1":
-                headers = append(headers, "SHA1")
-                extractors = append(extractors, func(ci ComponentInfo) string { return ci.Sha1 })
-            case "md5":
-                headers = append(headers, "MD5")
-                extractors = append(extractors, func(ci ComponentInfo) string { return ci.Md5 })
-            default:
-                return fmt.Errorf("invalid field: %s", field)
+    if total == 0 {
+        list.empty = "No projects found."
+    }
+    if items != nil {
+        if released > 0 || walkErr == nil {
+            if err := items.close(); err != nil {
+                return err
             }
         }
+        return walkErr
     }
+    list.items = components
+    if len(components) == 0 && walkErr != nil {
+        return walkErr
+    }
+    if err := printResources(cmd, list); err != nil {
+        return err
+    }
+    return walkErr
+}
 
-    // Prepare the table
-    w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-
-    // Print headers
-    fmt.Fprintln(w, strings.Join(headers, "\t"))
-
-    // Print separator
-    separator := make([]string, len(headers))
-    for i := range separator {
-        separator[i] = strings.Repeat("-", len(headers[i]))
+// componentList describes how components are printed. fields is the value of
+// --show-fields, naming extra columns to show in every tabular format.
+func componentList(components []dependencytrack.Component, fields string) (resourceList, error) {
+    component := func(item interface{}) dependencytrack.Component { return item.(dependencytrack.Component) }
+    list := resourceList{
+        kind:  "Component",
+        items: components,
+        columns: []column{
+            {header: "COMPONENT NAME", value: func(i interface{}) string { return component(i).Name }},
+            {header: "COMPONENT UUID", value: func(i interface{}) string { return component(i).UUID }},
+            {header: "VERSION", wide: true, value: func(i interface{}) string { return component(i).Version }},
+            {header: "PURL", wide: true, value: func(i interface{}) string { return component(i).PURL }},
+            {header: "PROJECT NAME", wide: true, value: func(i interface{}) string { return component(i).Project.Name }},
+        },
+        name:  func(i interface{}) string { return component(i).UUID },
+        empty: "No components found.",
     }
-    fmt.Fprintln(w, strings.Join(separator, "\t"))
 
-    // Print rows
-    for _, comp := range components {
-        row := make([]string, len(extractors))
-        for i, extract := range extractors {
-            row[i] = extract(comp)
+    if fields == "" {
+        return list, nil
+    }
+    extra := map[string]column{
+        "projectname": {header: "PROJECT NAME", value: func(i interface{}) string { return component(i).Project.Name }},
+        "projectuuid": {header: "PROJECT UUID", value: func(i interface{}) string { return component(i).Project.UUID }},
+        "sha256":      {header: "SHA256", value: func(i interface{}) string { return component(i).Sha256 }},
+        "sha1":        {header: "SHA1", value: func(i interface{}) string { return component(i).Sha1 }},
+        "md5":         {header: "MD5", value: func(i interface{}) string { return component(i).Md5 }},
+        "sha384":      {header: "SHA384", value: func(i interface{}) string { return component(i).Sha384 }},
+        "sha512":      {header: "SHA512", value: func(i interface{}) string { return component(i).Sha512 }},
+        "sha3_256":    {header: "SHA3_256", value: func(i interface{}) string { return component(i).Sha3256 }},
+        "sha3_384":    {header: "SHA3_384", value: func(i interface{}) string { return component(i).Sha3384 }},
+        "sha3_512":    {header: "SHA3_512", value: func(i interface{}) string { return component(i).Sha3512 }},
+        "blake2b_256": {header: "BLAKE2B_256", value: func(i interface{}) string { return component(i).Blake2b256 }},
+        "blake2b_384": {header: "BLAKE2B_384", value: func(i interface{}) string { return component(i).Blake2b384 }},
+        "blake2b_512": {header: "BLAKE2B_512", value: func(i interface{}) string { return component(i).Blake2b512 }},
+        "blake3":      {header: "BLAKE3", value: func(i interface{}) string { return component(i).Blake3 }},
+    }
+    for _, field := range parseFields(fields) {
+        col, ok := extra[field]
+        if !ok {
+            return list, fmt.Errorf("invalid field: %s", field)
         }
-        fmt.Fprintln(w, strings.Join(row, "\t"))
+        list.columns = showColumn(list.columns, col)
     }
+    return list, nil
+}
 
-    w.Flush()
-
-    return nil
+// showColumn appends col, dropping a wide-only column with the same header so
+// it is not shown twice.
+func showColumn(cols []column, col column) []column {
+    kept := cols[:0]
+    for _, c := range cols {
+        if c.header != col.header {
+            kept = append(kept, c)
+        }
+    }
+    return append(kept, col)
 }
 
 // Helper function to parse and normalize the show-fields input
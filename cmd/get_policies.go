@@ -1,19 +1,21 @@
 package cmd
 
 import (
-    "fmt"
-    "os"
-    "text/tabwriter"
+    "strconv"
 
     "github.com/spf13/cobra"
-    "dtctl/pkg/config"
     "dtctl/pkg/dependencytrack"
 )
 
-var showProjects bool
+var (
+    showProjects bool
+    policyLimit  int
+)
 
 func init() {
     getPoliciesCmd.Flags().BoolVar(&showProjects, "show-projects", false, "Show associated projects for each policy")
+    getPoliciesCmd.Flags().IntVar(&policyLimit, "limit", 0, "Maximum number of policies to fetch (0 for all)")
+    addOutputFlags(getPoliciesCmd)
 }
 
 var getPoliciesCmd = &cobra.Command{
@@ -23,51 +25,52 @@ var getPoliciesCmd = &cobra.Command{
 }
 
 func getPolicies(cmd *cobra.Command, args []string) error {
-    cfg, err := config.GetConfig()
+    client, err := newClient()
     if err != nil {
         return err
     }
-    if cfg.CurrentContext == "" {
-        return fmt.Errorf("no current context is set; use 'dtctl config use-context' to set one")
-    }
-    ctx, err := config.GetCurrentContext()
-    if err != nil {
-        return err
-    }
-    client := dependencytrack.NewClient(ctx.URL, ctx.Token)
+    ctx := cmd.Context()
 
-    policies, err := client.GetPolicies()
+    var policies []dependencytrack.Policy
+    err = client.WalkPoliciesContext(ctx, func(page []dependencytrack.Policy) error {
+        policies = append(policies, page...)
+        if policyLimit > 0 && len(policies) >= policyLimit {
+            policies = policies[:policyLimit]
+            return dependencytrack.ErrStopWalk
+        }
+        return nil
+    })
     if err != nil {
         return err
     }
 
-    if len(policies) == 0 {
-        fmt.Println("No policies found.")
-        return nil
-    }
+    return printResources(cmd, policyList(policies, showProjects))
+}
 
-    // Display data in table format
-    w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-    if showProjects {
-        fmt.Fprintln(w, "POLICY NAME\tPOLICY UUID\tPROJECTS")
-        fmt.Fprintln(w, "-----------\t-----------\t--------")
-        for _, policy := range policies {
-            var projectNames []string
-            for _, project := range policy.Projects {
-                projectNames = append(projectNames, project.Name)
-            }
-            fmt.Fprintf(w, "%s\t%s\t%s\n", policy.Name, policy.UUID, joinStrings(projectNames, ", "))
-        }
-    } else {
-        fmt.Fprintln(w, "POLICY NAME\tPOLICY UUID")
-        fmt.Fprintln(w, "-----------\t-----------")
-        for _, policy := range policies {
-            fmt.Fprintf(w, "%s\t%s\n", policy.Name, policy.UUID)
-        }
+// policyList describes how policies are printed. The PROJECTS column is
+// shown by -o wide, or in every tabular format when showProjects is set.
+func policyList(policies []dependencytrack.Policy, showProjects bool) resourceList {
+    policy := func(item interface{}) dependencytrack.Policy { return item.(dependencytrack.Policy) }
+    return resourceList{
+        kind:  "Policy",
+        items: policies,
+        columns: []column{
+            {header: "POLICY NAME", value: func(i interface{}) string { return policy(i).Name }},
+            {header: "POLICY UUID", value: func(i interface{}) string { return policy(i).UUID }},
+            {header: "OPERATOR", wide: true, value: func(i interface{}) string { return policy(i).Operator }},
+            {header: "VIOLATION STATE", wide: true, value: func(i interface{}) string { return policy(i).ViolationState }},
+            {header: "CONDITIONS", wide: true, value: func(i interface{}) string { return strconv.Itoa(len(policy(i).PolicyConditions)) }},
+            {header: "PROJECTS", wide: !showProjects, value: func(i interface{}) string {
+                var projectNames []string
+                for _, project := range policy(i).Projects {
+                    projectNames = append(projectNames, project.Name)
+                }
+                return joinStrings(projectNames, ", ")
+            }},
+        },
+        name:  func(i interface{}) string { return policy(i).UUID },
+        empty: "No policies found.",
     }
-    w.Flush()
-
-    return nil
 }
 
 // Helper function to join strings
@@ -1,76 +1,74 @@
 package cmd
 
 import (
-    "fmt"
-    "os"
-    "text/tabwriter"
+    "strings"
 
     "github.com/spf13/cobra"
-    "dtctl/pkg/config"
     "dtctl/pkg/dependencytrack"
 )
 
-var tag string
+var (
+    tag          string
+    projectLimit int
+)
 
 func init() {
     getCmd.AddCommand(getProjectsCmd)
     getProjectsCmd.Flags().StringVar(&tag, "tag", "", "Filter projects by tag")
+    getProjectsCmd.Flags().IntVar(&projectLimit, "limit", 0, "Maximum number of projects to fetch (0 for all)")
+    addOutputFlags(getProjectsCmd)
 }
 
 var getProjectsCmd = &cobra.Command{
     Use:   "projects",
     Short: "Get all projects",
     RunE: func(cmd *cobra.Command, args []string) error {
-        cfg, err := config.GetConfig()
+        client, err := newClient()
         if err != nil {
             return err
         }
-        if cfg.CurrentContext == "" {
-            return fmt.Errorf("no current context is set; use 'dtctl config use-context' to set one")
-        }
-
-        // Change this line:
-        // ctx, err := cfg.GetCurrentContext()
-        // To:
-        ctx, err := config.GetCurrentContext()
-        if err != nil {
-            return err
-        }
-
-        client := dependencytrack.NewClient(ctx.URL, ctx.Token)
+        ctx := cmd.Context()
 
         var projects []dependencytrack.Project
 
-        if tag != "" {
-            projects, err = client.GetProjectsByTag(tag)
-            if err != nil {
-                return err
+        err = walkProjects(ctx, client, tag, func(page []dependencytrack.Project) error {
+            projects = append(projects, page...)
+            if projectLimit > 0 && len(projects) >= projectLimit {
+                projects = projects[:projectLimit]
+                return dependencytrack.ErrStopWalk
             }
-        } else {
-            projects, err = client.GetProjects()
-            if err != nil {
-                return err
-            }
-        }
-
-        if len(projects) == 0 {
-            fmt.Println("No projects found.")
             return nil
+        })
+        if err != nil {
+            return err
         }
 
-        // Use tabwriter for formatted output
-        w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-        // Print header
-        fmt.Fprintln(w, "NAME\tUUID")
-        // Print separator line
-        fmt.Fprintln(w, "----\t----")
-        // Print project data
-        for _, project := range projects {
-            fmt.Fprintf(w, "%s\t%s\n", project.Name, project.UUID)
-        }
-        // Flush the writer to ensure all data is written
-        w.Flush()
-
-        return nil
+        return printResources(cmd, projectList(projects))
     },
 }
+
+// projectList describes how projects are printed.
+func projectList(projects []dependencytrack.Project) resourceList {
+    project := func(item interface{}) dependencytrack.Project { return item.(dependencytrack.Project) }
+    return resourceList{
+        kind:  "Project",
+        items: projects,
+        columns: []column{
+            {header: "NAME", value: func(i interface{}) string { return project(i).Name }},
+            {header: "UUID", value: func(i interface{}) string { return project(i).UUID }},
+            {header: "VERSION", wide: true, value: func(i interface{}) string { return project(i).Version }},
+            {header: "TAGS", wide: true, value: func(i interface{}) string { return tagNames(project(i).Tags) }},
+        },
+        name:  func(i interface{}) string { return project(i).UUID },
+        empty: "No projects found.",
+    }
+}
+
+// tagNames joins the names of tags with commas.
+func tagNames(tags []dependencytrack.Tag) string {
+    names := make([]string, len(tags))
+    for i, t := range tags {
+        names[i] = t.Name
+    }
+    return strings.Join(names, ",")
+}
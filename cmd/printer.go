@@ -0,0 +1,542 @@
+package cmd
+
+import (
+    "bytes"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "reflect"
+    "sort"
+    "strings"
+    "text/tabwriter"
+    "text/template"
+
+    "github.com/spf13/cobra"
+    "dtctl/pkg/jsonpath"
+    "gopkg.in/yaml.v2"
+)
+
+// listAPIVersion identifies the schema of -o json and -o yaml output.
+const listAPIVersion = "dtctl/v1"
+
+var (
+    // printFormat is the -o value of the get commands.
+    printFormat string
+    // noHeaders drops the header row from tabular output.
+    noHeaders bool
+    // templateFile holds the template for -o jsonpath or -o go-template.
+    templateFile string
+    // sortBy is a JSONPath expression items are sorted by before printing.
+    sortBy string
+    // allowMissingKeys makes templates print nothing for missing fields
+    // instead of failing.
+    allowMissingKeys bool
+
+    // outputMode is the format name of printFormat, without any template.
+    outputMode string
+    // outputTemplate renders the list document for the template formats.
+    outputTemplate func(w io.Writer, doc interface{}) error
+    // customColumns are the columns given by -o custom-columns. Their values
+    // are read from the generic form of each item.
+    customColumns []column
+    // sortPath is the compiled --sort-by expression.
+    sortPath *jsonpath.Path
+)
+
+// outputFormats lists the plain values accepted by -o.
+var outputFormats = []string{"table", "wide", "json", "yaml", "name", "csv", "tsv"}
+
+// templateFormats lists the -o values that take a template, given inline as
+// -o <format>=<template>, as a file path for the -file variants, or through
+// --template-file.
+var templateFormats = []string{"jsonpath", "jsonpath-file", "go-template", "go-template-file"}
+
+// addOutputFlags registers -o, --no-headers, --template-file, --sort-by and
+// --allow-missing-template-keys on cmd and validates them before the command
+// talks to the server.
+func addOutputFlags(cmd *cobra.Command) {
+    cmd.Flags().StringVarP(&printFormat, "output", "o", "table", "Output format: "+strings.Join(outputFormats, ", ")+", custom-columns=NAME:PATH,..., jsonpath=TEMPLATE, go-template=TEMPLATE, jsonpath-file=FILE or go-template-file=FILE")
+    cmd.Flags().BoolVar(&noHeaders, "no-headers", false, "Omit the header row from table, wide, custom-columns, csv and tsv output")
+    cmd.Flags().StringVar(&templateFile, "template-file", "", "File holding the template for -o jsonpath or -o go-template")
+    cmd.Flags().StringVar(&sortBy, "sort-by", "", "Sort items by the value at this JSONPath, e.g. .name or .project.name")
+    cmd.Flags().BoolVar(&allowMissingKeys, "allow-missing-template-keys", false, "Print nothing for missing fields and out-of-range indexes in -o jsonpath and -o go-template instead of failing")
+    cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+        return validateOutputFormat()
+    }
+}
+
+// validateOutputFormat checks the -o value and compiles its template, if any.
+func validateOutputFormat() error {
+    name, arg := printFormat, ""
+    if i := strings.IndexByte(printFormat, '='); i >= 0 {
+        name, arg = printFormat[:i], printFormat[i+1:]
+    }
+    outputMode, outputTemplate, customColumns, sortPath = name, nil, nil, nil
+
+    if sortBy != "" {
+        p, err := jsonpath.Compile(sortBy)
+        if err != nil {
+            return fmt.Errorf("invalid --sort-by: %v", err)
+        }
+        sortPath = p
+    }
+
+    if name == "custom-columns" {
+        cols, err := parseCustomColumns(arg)
+        if err != nil {
+            return err
+        }
+        customColumns = cols
+        return nil
+    }
+    for _, f := range outputFormats {
+        if name == f && arg == "" {
+            return nil
+        }
+    }
+    for _, f := range templateFormats {
+        if name == f {
+            text, err := templateText(name, arg)
+            if err != nil {
+                return err
+            }
+            outputTemplate, err = compileTemplate(name, text)
+            return err
+        }
+    }
+    return fmt.Errorf("unsupported output format: %s (supported: %s, custom-columns, %s)", printFormat, strings.Join(outputFormats, ", "), strings.Join(templateFormats, ", "))
+}
+
+// parseCustomColumns parses a custom-columns spec such as
+// "NAME:.name,PROJECT:.project.name" into columns reading generic items.
+func parseCustomColumns(spec string) ([]column, error) {
+    if spec == "" {
+        return nil, fmt.Errorf("-o custom-columns requires a column list, e.g. -o custom-columns=NAME:.name,UUID:.uuid")
+    }
+    var cols []column
+    for _, part := range strings.Split(spec, ",") {
+        i := strings.IndexByte(part, ':')
+        if i <= 0 || i == len(part)-1 {
+            return nil, fmt.Errorf("invalid custom column %q: expected NAME:PATH", part)
+        }
+        p, err := jsonpath.Compile(part[i+1:])
+        if err != nil {
+            return nil, fmt.Errorf("invalid custom column %q: %v", part, err)
+        }
+        cols = append(cols, column{header: part[:i], value: func(item interface{}) string {
+            values := p.Find(item)
+            if len(values) == 0 || (len(values) == 1 && values[0] == nil) {
+                return "<none>"
+            }
+            parts := make([]string, len(values))
+            for i, v := range values {
+                parts[i] = jsonpath.Format(v)
+            }
+            return strings.Join(parts, ",")
+        }})
+    }
+    return cols, nil
+}
+
+// templateText returns the template for a template format from its inline
+// argument, the file it names, or --template-file.
+func templateText(format, arg string) (string, error) {
+    path := templateFile
+    if strings.HasSuffix(format, "-file") {
+        if arg != "" {
+            path = arg
+        }
+    } else if arg != "" {
+        return arg, nil
+    }
+    if path == "" {
+        return "", fmt.Errorf("-o %s requires a template, e.g. -o jsonpath='{.items[*].uuid}' or --template-file", format)
+    }
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        return "", fmt.Errorf("failed to read template file: %w", err)
+    }
+    return string(data), nil
+}
+
+// compileTemplate parses text for a template format, so syntax errors are
+// reported before anything is fetched.
+func compileTemplate(format, text string) (func(w io.Writer, doc interface{}) error, error) {
+    if strings.HasPrefix(format, "jsonpath") {
+        t, err := jsonpath.Parse(text)
+        if err != nil {
+            return nil, fmt.Errorf("error parsing jsonpath: %v", err)
+        }
+        t.AllowMissingKeys(allowMissingKeys)
+        return func(w io.Writer, doc interface{}) error {
+            // Render fully first so a failing template prints nothing.
+            var buf bytes.Buffer
+            if err := t.Execute(&buf, doc); err != nil {
+                return fmt.Errorf("error executing jsonpath: %v", err)
+            }
+            _, err := buf.WriteTo(w)
+            return err
+        }, nil
+    }
+    missingKey := "missingkey=error"
+    if allowMissingKeys {
+        missingKey = "missingkey=default"
+    }
+    t, err := template.New("output").Option(missingKey).Parse(text)
+    if err != nil {
+        return nil, fmt.Errorf("error parsing go-template: %v", err)
+    }
+    return func(w io.Writer, doc interface{}) error {
+        var buf bytes.Buffer
+        if err := t.Execute(&buf, doc); err != nil {
+            return fmt.Errorf("error executing go-template: %v", err)
+        }
+        _, err := buf.WriteTo(w)
+        return err
+    }, nil
+}
+
+// column is one column of tabular output.
+type column struct {
+    header string
+    // wide columns are only shown by -o wide, csv and tsv.
+    wide  bool
+    value func(item interface{}) string
+}
+
+// resourceList is what a get command hands to printResources.
+type resourceList struct {
+    // kind names a single item, e.g. "Project". JSON and YAML output wrap
+    // the items in a document of kind "<kind>List".
+    kind string
+    // items is a slice of the resources to print.
+    items   interface{}
+    columns []column
+    // name returns the identifier printed by -o name.
+    name func(item interface{}) string
+    // empty is printed instead of an empty table.
+    empty string
+}
+
+// listDocument is the top-level object of -o json and -o yaml output.
+type listDocument struct {
+    APIVersion string        `json:"apiVersion"`
+    Kind       string        `json:"kind"`
+    Items      []interface{} `json:"items"`
+}
+
+// printResources writes list to the command's output in the -o format.
+func printResources(cmd *cobra.Command, list resourceList) error {
+    out := cmd.OutOrStdout()
+    items := listItems(list.items)
+
+    // Custom columns and sorting work on the same generic form of each item
+    // that templates see, so any field of the resource can be used.
+    var generics []interface{}
+    if sortPath != nil || customColumns != nil {
+        generics = make([]interface{}, len(items))
+        for i, item := range items {
+            g, err := genericDocument(item)
+            if err != nil {
+                return err
+            }
+            generics[i] = g
+        }
+    }
+    if sortPath != nil {
+        sortItems(items, generics, sortPath)
+    }
+
+    doc := listDocument{APIVersion: listAPIVersion, Kind: list.kind + "List", Items: items}
+    if outputTemplate != nil {
+        generic, err := genericDocument(doc)
+        if err != nil {
+            return err
+        }
+        return outputTemplate(out, generic)
+    }
+
+    switch outputMode {
+    case "custom-columns":
+        if len(items) == 0 {
+            fmt.Fprintln(out, list.empty)
+            return nil
+        }
+        return writeTable(out, customColumns, generics)
+    case "json", "yaml":
+        return writeStructured(out, outputMode, doc)
+    case "name":
+        for _, item := range items {
+            fmt.Fprintf(out, "%s/%s\n", strings.ToLower(list.kind), list.name(item))
+        }
+        return nil
+    case "csv", "tsv":
+        w := csv.NewWriter(out)
+        if outputMode == "tsv" {
+            w.Comma = '\t'
+        }
+        cols := visibleColumns(list.columns, true)
+        if !noHeaders {
+            w.Write(columnHeaders(cols))
+        }
+        for _, item := range items {
+            w.Write(columnValues(cols, item))
+        }
+        w.Flush()
+        return w.Error()
+    default:
+        if len(items) == 0 {
+            fmt.Fprintln(out, list.empty)
+            return nil
+        }
+        return writeTable(out, visibleColumns(list.columns, outputMode == "wide"), items)
+    }
+}
+
+// streamable reports whether items can be printed as they arrive instead of
+// all at once, which is the case for name, csv and tsv output without
+// --sort-by. Tables need every row to size their columns.
+func streamable() bool {
+    if sortPath != nil || outputTemplate != nil {
+        return false
+    }
+    switch outputMode {
+    case "name", "csv", "tsv":
+        return true
+    }
+    return false
+}
+
+// itemWriter prints the items of a list in a streamable format one batch at
+// a time.
+type itemWriter struct {
+    out  io.Writer
+    list resourceList
+    cols []column
+    csv  *csv.Writer
+}
+
+// newItemWriter returns an itemWriter printing to out in the -o format,
+// which must be streamable.
+func newItemWriter(out io.Writer, list resourceList) *itemWriter {
+    w := &itemWriter{out: out, list: list, cols: visibleColumns(list.columns, true)}
+    if outputMode == "csv" || outputMode == "tsv" {
+        w.csv = csv.NewWriter(out)
+        if outputMode == "tsv" {
+            w.csv.Comma = '\t'
+        }
+        if !noHeaders {
+            w.csv.Write(columnHeaders(w.cols))
+        }
+    }
+    return w
+}
+
+// write prints a batch of items.
+func (w *itemWriter) write(items []interface{}) error {
+    if w.csv == nil {
+        for _, item := range items {
+            if _, err := fmt.Fprintf(w.out, "%s/%s\n", strings.ToLower(w.list.kind), w.list.name(item)); err != nil {
+                return err
+            }
+        }
+        return nil
+    }
+    for _, item := range items {
+        w.csv.Write(columnValues(w.cols, item))
+    }
+    w.csv.Flush()
+    return w.csv.Error()
+}
+
+// close ends the output, flushing a csv header that no rows followed.
+func (w *itemWriter) close() error {
+    if w.csv == nil {
+        return nil
+    }
+    w.csv.Flush()
+    return w.csv.Error()
+}
+
+// writeTable writes items as aligned columns under a header and separator.
+func writeTable(out io.Writer, cols []column, items []interface{}) error {
+    w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+    if !noHeaders {
+        headers := columnHeaders(cols)
+        fmt.Fprintln(w, strings.Join(headers, "\t"))
+        separator := make([]string, len(headers))
+        for i, h := range headers {
+            separator[i] = strings.Repeat("-", len(h))
+        }
+        fmt.Fprintln(w, strings.Join(separator, "\t"))
+    }
+    for _, item := range items {
+        fmt.Fprintln(w, strings.Join(columnValues(cols, item), "\t"))
+    }
+    return w.Flush()
+}
+
+// sortItems stably sorts items, and generics alongside them, by the first
+// value path selects in each generic item. Numbers compare numerically,
+// anything else as text; items without a value sort first.
+func sortItems(items, generics []interface{}, path *jsonpath.Path) {
+    keys := make([]interface{}, len(items))
+    for i, g := range generics {
+        if values := path.Find(g); len(values) > 0 {
+            keys[i] = values[0]
+        }
+    }
+    idx := make([]int, len(items))
+    for i := range idx {
+        idx[i] = i
+    }
+    sort.SliceStable(idx, func(a, b int) bool {
+        return lessValue(keys[idx[a]], keys[idx[b]])
+    })
+    sortedItems := make([]interface{}, len(items))
+    sortedGenerics := make([]interface{}, len(items))
+    for i, j := range idx {
+        sortedItems[i], sortedGenerics[i] = items[j], generics[j]
+    }
+    copy(items, sortedItems)
+    copy(generics, sortedGenerics)
+}
+
+func lessValue(a, b interface{}) bool {
+    if a == nil || b == nil {
+        return a == nil && b != nil
+    }
+    an, aok := a.(json.Number)
+    bn, bok := b.(json.Number)
+    if aok && bok {
+        af, aerr := an.Float64()
+        bf, berr := bn.Float64()
+        if aerr == nil && berr == nil {
+            return af < bf
+        }
+    }
+    return jsonpath.Format(a) < jsonpath.Format(b)
+}
+
+// writeStructured writes v as indented JSON or as YAML. YAML is produced
+// from the JSON encoding so both formats share field names and field order.
+func writeStructured(out io.Writer, format string, v interface{}) error {
+    data, err := json.MarshalIndent(v, "", "  ")
+    if err != nil {
+        return err
+    }
+    if format == "json" {
+        _, err = fmt.Fprintln(out, string(data))
+        return err
+    }
+    dec := json.NewDecoder(bytes.NewReader(data))
+    dec.UseNumber()
+    ordered, err := decodeOrdered(dec)
+    if err != nil {
+        return err
+    }
+    data, err = yaml.Marshal(ordered)
+    if err != nil {
+        return err
+    }
+    _, err = out.Write(data)
+    return err
+}
+
+// genericDocument converts doc to the plain maps and slices that templates
+// walk, keyed by the same field names as -o json.
+func genericDocument(doc interface{}) (interface{}, error) {
+    data, err := json.Marshal(doc)
+    if err != nil {
+        return nil, err
+    }
+    dec := json.NewDecoder(bytes.NewReader(data))
+    dec.UseNumber()
+    var generic interface{}
+    if err := dec.Decode(&generic); err != nil {
+        return nil, err
+    }
+    return generic, nil
+}
+
+// decodeOrdered decodes the next JSON value from dec, turning objects into
+// yaml.MapSlice so their keys keep the order they were encoded in.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+    tok, err := dec.Token()
+    if err != nil {
+        return nil, err
+    }
+    switch tok {
+    case json.Delim('{'):
+        obj := yaml.MapSlice{}
+        for dec.More() {
+            key, err := dec.Token()
+            if err != nil {
+                return nil, err
+            }
+            value, err := decodeOrdered(dec)
+            if err != nil {
+                return nil, err
+            }
+            obj = append(obj, yaml.MapItem{Key: key, Value: value})
+        }
+        _, err = dec.Token()
+        return obj, err
+    case json.Delim('['):
+        arr := []interface{}{}
+        for dec.More() {
+            value, err := decodeOrdered(dec)
+            if err != nil {
+                return nil, err
+            }
+            arr = append(arr, value)
+        }
+        _, err = dec.Token()
+        return arr, err
+    }
+    if n, ok := tok.(json.Number); ok {
+        if i, err := n.Int64(); err == nil {
+            return i, nil
+        }
+        return n.Float64()
+    }
+    return tok, nil
+}
+
+// listItems converts a slice of any element type to []interface{}.
+func listItems(slice interface{}) []interface{} {
+    v := reflect.ValueOf(slice)
+    items := make([]interface{}, 0, v.Len())
+    for i := 0; i < v.Len(); i++ {
+        items = append(items, v.Index(i).Interface())
+    }
+    return items
+}
+
+func visibleColumns(cols []column, wide bool) []column {
+    var visible []column
+    for _, c := range cols {
+        if wide || !c.wide {
+            visible = append(visible, c)
+        }
+    }
+    return visible
+}
+
+func columnHeaders(cols []column) []string {
+    headers := make([]string, len(cols))
+    for i, c := range cols {
+        headers[i] = c.header
+    }
+    return headers
+}
+
+func columnValues(cols []column, item interface{}) []string {
+    values := make([]string, len(cols))
+    for i, c := range cols {
+        values[i] = c.value(item)
+    }
+    return values
+}
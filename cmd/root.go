@@ -1,20 +1,70 @@
 package cmd
 
 import (
+    "context"
     "fmt"
+    "os"
+    "os/signal"
+    "syscall"
+    "time"
 
     "github.com/spf13/cobra"
+    "dtctl/pkg/config"
+    "dtctl/pkg/dependencytrack"
+    "dtctl/pkg/logging"
+)
+
+var (
+    // contextName, serverURL and apiToken override the context commands run
+    // against; see config.ResolveContext.
+    contextName string
+    serverURL   string
+    apiToken    string
+    // requestTimeout bounds every HTTP request made to Dependency-Track.
+    requestTimeout time.Duration
+    // pageSize is the number of items fetched per request by list calls.
+    pageSize int
+    // maxRetries overrides the retry count of the current context when set.
+    maxRetries int
+    // recordFile and replayFile name a cassette to record the session to or
+    // to replay it from.
+    recordFile string
+    replayFile string
+    // verbosity and logFormat control the HTTP trace written to stderr.
+    verbosity int
+    logFormat string
+    // logger writes the trace; it is set up before any command runs.
+    logger *logging.Logger
 )
 
 var rootCmd = &cobra.Command{
     Use:     "dtctl",
     Short:   "dtctl is a CLI tool for interacting with Dependency-Track",
     Version: "", // Will set the version in init()
+    // main prints errors once, on stderr, without the usage text that
+    // would bury them.
+    SilenceUsage:  true,
+    SilenceErrors: true,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+        logger, err = logging.New(cmd.ErrOrStderr(), logFormat)
+        return err
+    },
 }
 
-// Execute executes the root command.
+// RootCommand returns the dtctl command tree, for tools that embed it. Use
+// SetClientFactory to point it at another backend and SetOut/SetErr on the
+// returned command to capture its output.
+func RootCommand() *cobra.Command {
+    return rootCmd
+}
+
+// Execute executes the root command. SIGINT and SIGTERM cancel the command's
+// context so in-flight requests are aborted instead of left hanging.
 func Execute() error {
-    return rootCmd.Execute()
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+    return rootCmd.ExecuteContext(ctx)
 }
 
 func init() {
@@ -24,6 +74,17 @@ func init() {
     // Customize the version output format
     rootCmd.SetVersionTemplate(fmt.Sprintf("dtctl %s\n", GetVersion()))
 
+    rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "Name of the context to use instead of the current one (env "+config.ContextEnv+")")
+    rootCmd.PersistentFlags().StringVar(&serverURL, "server", "", "Dependency-Track server URL, overriding the context's (env "+config.URLEnv+")")
+    rootCmd.PersistentFlags().StringVar(&apiToken, "token", "", "API token, overriding the context's (env "+config.TokenEnv+")")
+    rootCmd.PersistentFlags().DurationVar(&requestTimeout, "timeout", dependencytrack.DefaultTimeout, "Timeout for each request to the server (0 disables the timeout)")
+    rootCmd.PersistentFlags().IntVar(&pageSize, "page-size", dependencytrack.DefaultPageSize, "Number of items fetched per request when listing resources")
+    rootCmd.PersistentFlags().StringVar(&recordFile, "record", "", "Record every request and response to this cassette file, with the API key redacted")
+    rootCmd.PersistentFlags().StringVar(&replayFile, "replay", "", "Answer requests from this cassette file instead of contacting the server")
+    rootCmd.PersistentFlags().IntVarP(&verbosity, "verbosity", "v", 0, "Log HTTP requests to stderr: 1 logs method, URL, status and latency, 7 adds headers, 9 adds bodies")
+    rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", logging.FormatText, "Format of log output: text or json")
+    rootCmd.PersistentFlags().IntVar(&maxRetries, "max-retries", dependencytrack.DefaultRetryPolicy().MaxRetries, "Number of times to retry a request that failed with a transient error (overrides the context setting)")
+
     // Add subcommands
     rootCmd.AddCommand(configCmd)
     rootCmd.AddCommand(getCmd)
@@ -4,8 +4,6 @@ import (
     "fmt"
 
     "github.com/spf13/cobra"
-    "dtctl/pkg/config"
-    "dtctl/pkg/dependencytrack"
 )
 
 var (
@@ -30,28 +28,19 @@ func init() {
 
 // setComponent handles the execution of the set component command
 func setComponent(cmd *cobra.Command, args []string) error {
-    // Retrieve configuration
-    cfg, err := config.GetConfig()
-    if err != nil {
-        return err
-    }
-    if cfg.CurrentContext == "" {
-        return fmt.Errorf("no current context is set; use 'dtctl config use-context' to set one")
-    }
-    ctx, err := config.GetCurrentContext()
+    // Initialize the Dependency-Track client
+    client, err := newClient()
     if err != nil {
         return err
     }
-
-    // Initialize the Dependency-Track client
-    client := dependencytrack.NewClient(ctx.URL, ctx.Token)
+    ctx := cmd.Context()
 
     // Update the sha256 field
-    err = client.UpdateComponentSHA256(componentUUID, newSHA256)
+    err = client.UpdateComponentSHA256Context(ctx, componentUUID, newSHA256)
     if err != nil {
-        return fmt.Errorf("failed to update component: %v", err)
+        return fmt.Errorf("failed to update component: %w", err)
     }
 
-    fmt.Println("Component sha256 updated successfully.")
+    fmt.Fprintln(cmd.OutOrStdout(), "Component sha256 updated successfully.")
     return nil
 }
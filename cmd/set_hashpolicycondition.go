@@ -4,9 +4,8 @@ package cmd
 
 import (
     "fmt"
-    "dtctl/pkg/config"
+    "strings"
     "dtctl/pkg/dependencytrack"
-    "encoding/json"
 
     "github.com/spf13/cobra"
 )
@@ -47,47 +46,38 @@ func init() {
 
 // setHashPolicyCondition handles the execution of the set hashpolicycondition command
 func setHashPolicyCondition(cmd *cobra.Command, args []string) error {
-    // Retrieve configuration
-    cfg, err := config.GetConfig()
-    if err != nil {
-        return err
-    }
-    if cfg.CurrentContext == "" {
-        return fmt.Errorf("no current context is set; use 'dtctl config use-context' to set one")
-    }
-    ctx, err := config.GetCurrentContext()
+    // Initialize the Dependency-Track client
+    client, err := newClient()
     if err != nil {
         return err
     }
+    ctx := cmd.Context()
 
-    // Initialize the Dependency-Track client
-    client := dependencytrack.NewClient(ctx.URL, ctx.Token)
+    algorithm := dependencytrack.CanonicalHashAlgorithm(hcAlgorithm)
+    if algorithm == "" {
+        return fmt.Errorf("unsupported hash algorithm %q; use one of %s", hcAlgorithm, strings.Join(dependencytrack.HashAlgorithms(), ", "))
+    }
 
     // Construct the value field as a JSON string
-    valueObj := map[string]string{
-        "algorithm": hcAlgorithm,
-        "value":     hcAlgorithmValue,
-    }
-    valueBytes, err := json.Marshal(valueObj)
-    if err != nil {
-        return fmt.Errorf("failed to marshal value object: %v", err)
+    hash := dependencytrack.HashValue{
+        Algorithm: algorithm,
+        Value:     hcAlgorithmValue,
     }
-    valueStr := string(valueBytes)
 
     // Create the PolicyCondition struct
     condition := dependencytrack.PolicyCondition{
         Operator: hcOperator,
         Subject:  hcSubject,
-        Value:    valueStr,
+        Value:    hash.Encode(),
         UUID:     hcUUID,
     }
 
     // Update the policy condition
-    err = client.UpdatePolicyCondition(condition)
+    err = client.UpdatePolicyConditionContext(ctx, condition)
     if err != nil {
-        return fmt.Errorf("failed to update policy condition: %v", err)
+        return fmt.Errorf("failed to update policy condition: %w", err)
     }
 
-    fmt.Println("Policy condition updated successfully.")
+    fmt.Fprintln(cmd.OutOrStdout(), "Policy condition updated successfully.")
     return nil
 }
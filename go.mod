@@ -4,5 +4,6 @@ go 1.16
 
 require (
 	github.com/spf13/cobra v1.6.1
+	github.com/spf13/pflag v1.0.5
 	gopkg.in/yaml.v2 v2.4.0
 )
@@ -1,15 +1,47 @@
 package main
 
 import (
+    "errors"
     "fmt"
+    "net/http"
     "os"
 
     "dtctl/cmd"
+    "dtctl/pkg/dependencytrack"
+)
+
+// Process exit codes. Scripts can rely on these to tell failures apart
+// without parsing error messages.
+const (
+    exitError       = 1 // any failure not covered below
+    exitAuth        = 3 // 401 or 403 from the server
+    exitNotFound    = 4 // 404 from the server
+    exitConflict    = 5 // 409 from the server
+    exitServerError = 6 // 5xx from the server
 )
 
 func main() {
     if err := cmd.Execute(); err != nil {
-        fmt.Println(err)
-        os.Exit(1)
+        fmt.Fprintln(os.Stderr, "Error:", err)
+        os.Exit(exitCode(err))
+    }
+}
+
+// exitCode maps err to a process exit code.
+func exitCode(err error) int {
+    var apiErr *dependencytrack.APIError
+    if !errors.As(err, &apiErr) {
+        return exitError
+    }
+    switch {
+    case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+        return exitAuth
+    case apiErr.StatusCode == http.StatusNotFound:
+        return exitNotFound
+    case apiErr.StatusCode == http.StatusConflict:
+        return exitConflict
+    case apiErr.StatusCode >= 500:
+        return exitServerError
     }
+    return exitError
 }
@@ -2,6 +2,7 @@ package config
 
 import (
     "encoding/json"
+    "errors"
     "fmt"
     "io/ioutil"
     "os"
@@ -11,88 +12,189 @@ import (
 type Context struct {
     Name  string `json:"name"`
     URL   string `json:"url"`
-    Token string `json:"token"`
+    // Token is the API token stored in plain text. The token can instead
+    // be kept out of the config with TokenFile, TokenEnv or Exec, or stored
+    // encrypted with a passphrase in EncryptedToken; see ResolveToken.
+    Token          string          `json:"token,omitempty"`
+    TokenFile      string          `json:"token_file,omitempty"`
+    TokenEnv       string          `json:"token_env,omitempty"`
+    Exec           *ExecCredential `json:"exec,omitempty"`
+    EncryptedToken string          `json:"encrypted_token,omitempty"`
+    // MaxRetries overrides the client's default retry count when set.
+    MaxRetries *int `json:"max_retries,omitempty"`
+    // RetryUpdates allows retrying the POST requests used by set commands.
+    RetryUpdates bool `json:"retry_updates,omitempty"`
+
+    // CertificateAuthority is a PEM file of extra CAs to trust, and
+    // CertificateAuthorityData the same embedded in the config.
+    CertificateAuthority     string `json:"certificate_authority,omitempty"`
+    CertificateAuthorityData []byte `json:"certificate_authority_data,omitempty"`
+    // ClientCertificate and ClientKey, as files or embedded data, are used
+    // for mutual TLS.
+    ClientCertificate     string `json:"client_certificate,omitempty"`
+    ClientCertificateData []byte `json:"client_certificate_data,omitempty"`
+    ClientKey             string `json:"client_key,omitempty"`
+    ClientKeyData         []byte `json:"client_key_data,omitempty"`
+    // InsecureSkipTLSVerify disables verification of the server certificate.
+    InsecureSkipTLSVerify bool `json:"insecure_skip_tls_verify,omitempty"`
+    // ProxyURL is the proxy requests are sent through.
+    ProxyURL string `json:"proxy_url,omitempty"`
+    // ServerName overrides the name the server certificate is checked against.
+    ServerName string `json:"server_name,omitempty"`
 }
 
 type Config struct {
+    // APIVersion is the schema version of the config file. Files written by
+    // older versions of dtctl are migrated when read, see migrations.
+    APIVersion     string    `json:"apiVersion"`
     CurrentContext string    `json:"current_context"`
     Contexts       []Context `json:"contexts"`
 }
 
-var configFilePath string
+// Environment variables read by dtctl. DTCTL_CONFIG replaces the config file
+// path; the others override the context, URL and token, see ResolveContext.
+const (
+    ConfigEnv  = "DTCTL_CONFIG"
+    ContextEnv = "DTCTL_CONTEXT"
+    URLEnv     = "DTCTL_URL"
+    TokenEnv   = "DTCTL_TOKEN"
+)
+
+// ErrNoContext is returned by ResolveContext when no context is selected and
+// no server URL is given.
+var ErrNoContext = errors.New("no current context is set; use 'dtctl config use-context' to set one")
 
-func init() {
+// Path returns the path of the config file: DTCTL_CONFIG if set, otherwise
+// ~/.dtctl/config.json.
+func Path() (string, error) {
+    if path := os.Getenv(ConfigEnv); path != "" {
+        return path, nil
+    }
     homeDir, err := os.UserHomeDir()
     if err != nil {
-        fmt.Println("Error getting home directory:", err)
-        os.Exit(1)
+        return "", fmt.Errorf("failed to locate the config file: %v; set %s", err, ConfigEnv)
     }
-    configDir := filepath.Join(homeDir, ".dtctl")
-    if _, err := os.Stat(configDir); os.IsNotExist(err) {
-        os.Mkdir(configDir, 0755)
-    }
-    configFilePath = filepath.Join(configDir, "config.json")
+    return filepath.Join(homeDir, ".dtctl", "config.json"), nil
 }
 
 func loadConfig() (*Config, error) {
-    var cfg Config
-    if _, err := os.Stat(configFilePath); os.IsNotExist(err) {
-        return &cfg, nil
-    }
-    data, err := ioutil.ReadFile(configFilePath)
+    path, err := Path()
     if err != nil {
         return nil, err
     }
-    err = json.Unmarshal(data, &cfg)
+    return readConfig(path)
+}
+
+// readConfig reads the config file at path, migrating it to APIVersion. A
+// missing file is an empty config.
+func readConfig(path string) (*Config, error) {
+    data, err := ioutil.ReadFile(path)
+    if os.IsNotExist(err) {
+        return &Config{APIVersion: APIVersion}, nil
+    }
     if err != nil {
         return nil, err
     }
-    return &cfg, nil
+    cfg, err := decodeConfig(data)
+    if err != nil {
+        return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+    }
+    return cfg, nil
 }
 
-func saveConfig(cfg *Config) error {
+// writeConfig replaces the config file at path atomically: the new content
+// is written to a temporary file in the same directory, which is then
+// renamed over the old one, so readers never see a partial file.
+func writeConfig(path string, cfg *Config) error {
+    cfg.APIVersion = APIVersion
     data, err := json.MarshalIndent(cfg, "", "  ")
     if err != nil {
         return err
     }
-    err = ioutil.WriteFile(configFilePath, data, 0644)
+    dir := filepath.Dir(path)
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return err
+    }
+    tmp, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp-")
     if err != nil {
         return err
     }
-    return nil
+    defer os.Remove(tmp.Name())
+    // The config holds credentials, so only its owner may read it. TempFile
+    // already creates files with mode 0600; the chmod is for umasks and
+    // platforms where it does not.
+    if err := tmp.Chmod(0600); err != nil {
+        tmp.Close()
+        return err
+    }
+    if _, err := tmp.Write(data); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Sync(); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), path)
 }
 
-func AddContext(ctx Context) error {
-    cfg, err := loadConfig()
+// updateConfig loads the config, applies fn to it and saves the result,
+// holding the config lock throughout so concurrent dtctl processes cannot
+// overwrite each other's changes. Nothing is saved when fn fails.
+func updateConfig(fn func(cfg *Config) error) error {
+    path, err := Path()
     if err != nil {
         return err
     }
-    for _, c := range cfg.Contexts {
-        if c.Name == ctx.Name {
-            return fmt.Errorf("context '%s' already exists", ctx.Name)
-        }
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+        return err
     }
-    cfg.Contexts = append(cfg.Contexts, ctx)
-    return saveConfig(cfg)
-}
-
-func UseContext(name string) error {
-    cfg, err := loadConfig()
+    unlock, err := lockConfig(path)
     if err != nil {
         return err
     }
-    found := false
-    for _, c := range cfg.Contexts {
-        if c.Name == name {
-            found = true
-            break
-        }
+    defer unlock()
+
+    cfg, err := readConfig(path)
+    if err != nil {
+        return err
     }
-    if !found {
-        return fmt.Errorf("context '%s' not found", name)
+    if err := fn(cfg); err != nil {
+        return err
     }
-    cfg.CurrentContext = name
-    return saveConfig(cfg)
+    return writeConfig(path, cfg)
+}
+
+func AddContext(ctx Context) error {
+    return updateConfig(func(cfg *Config) error {
+        for _, c := range cfg.Contexts {
+            if c.Name == ctx.Name {
+                return fmt.Errorf("context '%s' already exists", ctx.Name)
+            }
+        }
+        cfg.Contexts = append(cfg.Contexts, ctx)
+        return nil
+    })
+}
+
+func UseContext(name string) error {
+    return updateConfig(func(cfg *Config) error {
+        found := false
+        for _, c := range cfg.Contexts {
+            if c.Name == name {
+                found = true
+                break
+            }
+        }
+        if !found {
+            return fmt.Errorf("context '%s' not found", name)
+        }
+        cfg.CurrentContext = name
+        return nil
+    })
 }
 
 func GetConfig() (*Config, error) {
@@ -123,21 +225,185 @@ func GetCurrentContext() (*Context, error) {
     return GetContext(cfg.CurrentContext)
 }
 
-func UpdateContext(updatedCtx Context) error {
+// Overrides adjust the context a command runs against. Empty fields are
+// ignored.
+type Overrides struct {
+    // Context selects a context by name instead of the current one.
+    Context string
+    // Server and Token replace the URL and token of the selected context.
+    Server string
+    Token  string
+}
+
+// ResolveContext returns the context a command should use, with its Token
+// resolved. Each setting is taken from the first of overrides, the
+// DTCTL_CONTEXT, DTCTL_URL and DTCTL_TOKEN environment variables, and the
+// config file that is set. When no context is selected but a server URL is
+// given, an unnamed context for that server is returned, so no config file
+// is needed at all.
+func ResolveContext(overrides Overrides) (*Context, error) {
     cfg, err := loadConfig()
     if err != nil {
-        return err
+        return nil, err
     }
-    found := false
-    for i, ctx := range cfg.Contexts {
-        if ctx.Name == updatedCtx.Name {
-            cfg.Contexts[i] = updatedCtx
-            found = true
-            break
+    ctx, err := effectiveContext(cfg, overrides)
+    if err != nil {
+        return nil, err
+    }
+    if firstNonEmpty(overrides.Token, os.Getenv(TokenEnv)) != "" {
+        return ctx, nil
+    }
+    resolved, err := ctx.ResolveToken()
+    if err != nil {
+        return nil, err
+    }
+    ctx.ClearToken()
+    ctx.Token = resolved
+    return ctx, nil
+}
+
+// effectiveContext returns a copy of the context selected by overrides, the
+// environment or cfg, with the URL and token overrides applied but the token
+// not yet resolved.
+func effectiveContext(cfg *Config, overrides Overrides) (*Context, error) {
+    name := firstNonEmpty(overrides.Context, os.Getenv(ContextEnv), cfg.CurrentContext)
+    server := firstNonEmpty(overrides.Server, os.Getenv(URLEnv))
+    token := firstNonEmpty(overrides.Token, os.Getenv(TokenEnv))
+
+    ctx := &Context{}
+    if name != "" {
+        c := cfg.context(name)
+        if c == nil {
+            return nil, fmt.Errorf("context '%s' not found", name)
+        }
+        copied := *c
+        ctx = &copied
+    } else if server == "" {
+        return nil, ErrNoContext
+    }
+
+    if server != "" {
+        ctx.URL = server
+    }
+    if token != "" {
+        ctx.ClearToken()
+        ctx.Token = token
+    }
+    return ctx, nil
+}
+
+// CurrentContextName returns the name of the context commands run against:
+// the Context override, DTCTL_CONTEXT or the current context of the config
+// file. It returns ErrNoContext when none is set.
+func CurrentContextName(overrides Overrides) (string, error) {
+    name := firstNonEmpty(overrides.Context, os.Getenv(ContextEnv))
+    if name == "" {
+        cfg, err := loadConfig()
+        if err != nil {
+            return "", err
+        }
+        name = cfg.CurrentContext
+    }
+    if name == "" {
+        return "", ErrNoContext
+    }
+    return name, nil
+}
+
+// EffectiveConfig returns the config as commands see it with overrides, the
+// DTCTL_CONTEXT, DTCTL_URL and DTCTL_TOKEN environment variables applied: the
+// selected context becomes the current one and carries the URL and token
+// overrides. Tokens are not resolved, so no credential commands are run.
+// When no context is selected but a server URL is given, an unnamed context
+// for that server is added.
+func EffectiveConfig(overrides Overrides) (*Config, error) {
+    cfg, err := loadConfig()
+    if err != nil {
+        return nil, err
+    }
+    ctx, err := effectiveContext(cfg, overrides)
+    if err == ErrNoContext {
+        return cfg, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    cfg.CurrentContext = ctx.Name
+    if c := cfg.context(ctx.Name); c != nil {
+        *c = *ctx
+    } else {
+        cfg.Contexts = append(cfg.Contexts, *ctx)
+    }
+    return cfg, nil
+}
+
+// context returns the context of cfg with the given name, or nil.
+func (cfg *Config) context(name string) *Context {
+    for i := range cfg.Contexts {
+        if cfg.Contexts[i].Name == name {
+            return &cfg.Contexts[i]
         }
     }
-    if !found {
+    return nil
+}
+
+func firstNonEmpty(values ...string) string {
+    for _, v := range values {
+        if v != "" {
+            return v
+        }
+    }
+    return ""
+}
+
+func UpdateContext(updatedCtx Context) error {
+    return updateConfig(func(cfg *Config) error {
+        for i, ctx := range cfg.Contexts {
+            if ctx.Name == updatedCtx.Name {
+                cfg.Contexts[i] = updatedCtx
+                return nil
+            }
+        }
         return fmt.Errorf("context '%s' not found", updatedCtx.Name)
+    })
+}
+
+// DeleteContext removes the named context. When it was the current context,
+// no context is current afterwards and wasCurrent is true.
+func DeleteContext(name string) (wasCurrent bool, err error) {
+    err = updateConfig(func(cfg *Config) error {
+        for i, ctx := range cfg.Contexts {
+            if ctx.Name == name {
+                cfg.Contexts = append(cfg.Contexts[:i], cfg.Contexts[i+1:]...)
+                if cfg.CurrentContext == name {
+                    cfg.CurrentContext = ""
+                    wasCurrent = true
+                }
+                return nil
+            }
+        }
+        return fmt.Errorf("context '%s' not found", name)
+    })
+    return wasCurrent, err
+}
+
+// RenameContext renames a context, keeping it current if it was.
+func RenameContext(oldName, newName string) error {
+    if newName == "" {
+        return fmt.Errorf("the new context name must not be empty")
     }
-    return saveConfig(cfg)
+    return updateConfig(func(cfg *Config) error {
+        ctx := cfg.context(oldName)
+        if ctx == nil {
+            return fmt.Errorf("context '%s' not found", oldName)
+        }
+        if newName != oldName && cfg.context(newName) != nil {
+            return fmt.Errorf("context '%s' already exists", newName)
+        }
+        ctx.Name = newName
+        if cfg.CurrentContext == oldName {
+            cfg.CurrentContext = newName
+        }
+        return nil
+    })
 }
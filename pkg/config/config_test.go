@@ -0,0 +1,213 @@
+package config
+
+import (
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+)
+
+// useConfigFile points DTCTL_CONFIG at a config file in a temporary
+// directory, optionally with the given content.
+func useConfigFile(t *testing.T, content string) string {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), "config.json")
+    if content != "" {
+        if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+            t.Fatal(err)
+        }
+    }
+    old, had := os.LookupEnv(ConfigEnv)
+    os.Setenv(ConfigEnv, path)
+    t.Cleanup(func() {
+        if had {
+            os.Setenv(ConfigEnv, old)
+        } else {
+            os.Unsetenv(ConfigEnv)
+        }
+    })
+    return path
+}
+
+func TestMigrateLegacyConfig(t *testing.T) {
+    path := useConfigFile(t, `{"current_context": "prod", "contexts": [{"name": "prod", "url": "https://dt.example.com", "token": "secret"}]}`)
+
+    ctx, err := GetContext("prod")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if ctx.URL != "https://dt.example.com" || ctx.Token != "secret" {
+        t.Errorf("unexpected context after migration: %+v", ctx)
+    }
+
+    if err := UseContext("prod"); err != nil {
+        t.Fatal(err)
+    }
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(string(data), `"apiVersion": "`+APIVersion+`"`) {
+        t.Errorf("saved config has no apiVersion:\n%s", data)
+    }
+}
+
+func TestUnsupportedAPIVersion(t *testing.T) {
+    useConfigFile(t, `{"apiVersion": "dtctl/v99", "contexts": []}`)
+    _, err := GetConfig()
+    if err == nil || !strings.Contains(err.Error(), `unsupported apiVersion "dtctl/v99"`) {
+        t.Fatalf("GetConfig: err = %v", err)
+    }
+}
+
+func TestFailedUpdateKeepsConfig(t *testing.T) {
+    const content = `{"apiVersion": "dtctl/v1", "current_context": "", "contexts": [{"name": "prod", "url": "https://dt.example.com"}]}`
+    path := useConfigFile(t, content)
+    if err := AddContext(Context{Name: "prod"}); err == nil {
+        t.Fatal("adding a duplicate context succeeded")
+    }
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if string(data) != content {
+        t.Errorf("config changed by a failed update:\n%s", data)
+    }
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+    path := useConfigFile(t, "")
+
+    const n = 20
+    var wg sync.WaitGroup
+    errs := make(chan error, n)
+    for i := 0; i < n; i++ {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            errs <- AddContext(Context{Name: fmt.Sprintf("ctx-%d", i), URL: "https://dt.example.com"})
+        }(i)
+    }
+    wg.Wait()
+    close(errs)
+    for err := range errs {
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    cfg, err := GetConfig()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(cfg.Contexts) != n {
+        t.Errorf("got %d contexts, want %d; updates were lost", len(cfg.Contexts), n)
+    }
+
+    fi, err := os.Stat(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if perm := fi.Mode().Perm(); perm != 0600 && filepath.Separator == '/' {
+        t.Errorf("config file mode = %v, want 0600", perm)
+    }
+    entries, err := ioutil.ReadDir(filepath.Dir(path))
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, e := range entries {
+        if e.Name() != "config.json" && e.Name() != "config.json.lock" {
+            t.Errorf("temporary file left behind: %s", e.Name())
+        }
+    }
+}
+
+func TestDeleteAndRenameContext(t *testing.T) {
+    useConfigFile(t, `{"apiVersion": "dtctl/v1", "current_context": "prod", "contexts": [{"name": "prod"}, {"name": "test"}]}`)
+
+    if err := RenameContext("prod", "production"); err != nil {
+        t.Fatal(err)
+    }
+    if err := RenameContext("test", "production"); err == nil {
+        t.Error("renaming onto an existing context succeeded")
+    }
+    if name, err := CurrentContextName(Overrides{}); err != nil || name != "production" {
+        t.Errorf("current context after rename = %q, %v; want production", name, err)
+    }
+
+    if wasCurrent, err := DeleteContext("test"); err != nil || wasCurrent {
+        t.Errorf("DeleteContext(test) = %v, %v", wasCurrent, err)
+    }
+    if wasCurrent, err := DeleteContext("production"); err != nil || !wasCurrent {
+        t.Errorf("DeleteContext(production) = %v, %v", wasCurrent, err)
+    }
+    if _, err := CurrentContextName(Overrides{}); err != ErrNoContext {
+        t.Errorf("current context after delete: err = %v, want ErrNoContext", err)
+    }
+    if _, err := DeleteContext("production"); err == nil {
+        t.Error("deleting a missing context succeeded")
+    }
+}
+
+func TestSetProperty(t *testing.T) {
+    useConfigFile(t, `{"apiVersion": "dtctl/v1", "contexts": [{"name": "dt"}, {"name": "dt.prod", "token": "t"}]}`)
+
+    for _, kv := range [][2]string{
+        {"contexts.dt.prod.url", "https://dt.example.com"},
+        {"contexts.dt.prod.max_retries", "3"},
+        {"contexts.dt.prod.retry_updates", "true"},
+        {"contexts.dt.prod.client_key_data", "a2V5"},
+        {"contexts.dt.exec.command", "vault"},
+        {"contexts.dt.exec.args", "read,secret/dt"},
+        {"current_context", "dt.prod"},
+    } {
+        if err := SetProperty(kv[0], kv[1]); err != nil {
+            t.Fatalf("SetProperty(%s): %v", kv[0], err)
+        }
+    }
+    ctx, err := GetContext("dt.prod")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if ctx.URL != "https://dt.example.com" || ctx.MaxRetries == nil || *ctx.MaxRetries != 3 || !ctx.RetryUpdates || string(ctx.ClientKeyData) != "key" {
+        t.Errorf("unexpected context after set: %+v", ctx)
+    }
+    dt, err := GetContext("dt")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if dt.Exec == nil || dt.Exec.Command != "vault" || strings.Join(dt.Exec.Args, " ") != "read secret/dt" {
+        t.Errorf("unexpected exec credential after set: %+v", dt.Exec)
+    }
+
+    for _, kv := range [][2]string{
+        {"contexts.dt.prod.max_retries", "many"},
+        {"contexts.dt.prod.retry_updates", "yes please"},
+        {"contexts.dt.prod.token_env", "DT_TOKEN"},
+        {"contexts.dt.prod.name", "other"},
+        {"contexts.dt.prod.exec", "vault"},
+        {"contexts.missing.url", "https://dt.example.com"},
+        {"current_context", "missing"},
+        {"apiVersion", "dtctl/v2"},
+    } {
+        if err := SetProperty(kv[0], kv[1]); err == nil {
+            t.Errorf("SetProperty(%s, %s) succeeded", kv[0], kv[1])
+        }
+    }
+
+    if err := UnsetProperty("contexts.dt.exec"); err != nil {
+        t.Fatal(err)
+    }
+    if err := UnsetProperty("contexts.dt.prod.max_retries"); err != nil {
+        t.Fatal(err)
+    }
+    if dt, _ := GetContext("dt"); dt.Exec != nil {
+        t.Errorf("exec credential left after unset: %+v", dt.Exec)
+    }
+    if ctx, _ := GetContext("dt.prod"); ctx.MaxRetries != nil {
+        t.Errorf("max_retries left after unset: %d", *ctx.MaxRetries)
+    }
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+    "bytes"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "os/exec"
+    "strings"
+)
+
+// PassphraseEnv names the environment variable holding the passphrase for
+// encrypted tokens.
+const PassphraseEnv = "DTCTL_PASSPHRASE"
+
+// PassphrasePrompt asks the user for the passphrase of the named context when
+// PassphraseEnv is not set. It is nil when there is no one to ask.
+var PassphrasePrompt func(contextName string) (string, error)
+
+// ExecCredential runs a command that prints an API token on stdout, like a
+// kubectl exec credential plugin. Its stderr and stdin are the user's, so it
+// can prompt for a login.
+type ExecCredential struct {
+    Command string   `json:"command"`
+    Args    []string `json:"args,omitempty"`
+    // Env holds extra KEY=value variables for the command.
+    Env []string `json:"env,omitempty"`
+}
+
+// TokenSource describes where the API token of c comes from, without
+// revealing it, e.g. "token-file /etc/dtctl/token". It is empty when c has
+// no token.
+func (c *Context) TokenSource() string {
+    sources := c.tokenSources()
+    if len(sources) == 0 {
+        return ""
+    }
+    return strings.Join(sources, ", ")
+}
+
+func (c *Context) tokenSources() []string {
+    var sources []string
+    if c.Token != "" {
+        sources = append(sources, "token")
+    }
+    if c.EncryptedToken != "" {
+        sources = append(sources, "encrypted-token")
+    }
+    if c.TokenFile != "" {
+        sources = append(sources, "token-file "+c.TokenFile)
+    }
+    if c.TokenEnv != "" {
+        sources = append(sources, "token-env "+c.TokenEnv)
+    }
+    if c.Exec != nil {
+        sources = append(sources, "exec "+c.Exec.Command)
+    }
+    return sources
+}
+
+// ClearToken removes every token source from c.
+func (c *Context) ClearToken() {
+    c.Token = ""
+    c.EncryptedToken = ""
+    c.TokenFile = ""
+    c.TokenEnv = ""
+    c.Exec = nil
+}
+
+// ResolveToken returns the API token of c, reading it from its file or
+// environment variable, running its exec credential, or decrypting it as
+// configured. A context may have at most one token source.
+func (c *Context) ResolveToken() (string, error) {
+    if sources := c.tokenSources(); len(sources) > 1 {
+        return "", fmt.Errorf("context '%s' has more than one token source: %s", c.Name, strings.Join(sources, ", "))
+    }
+
+    switch {
+    case c.TokenFile != "":
+        data, err := ioutil.ReadFile(c.TokenFile)
+        if err != nil {
+            return "", fmt.Errorf("failed to read token file: %v", err)
+        }
+        return nonEmptyToken(string(data), "token file "+c.TokenFile)
+    case c.TokenEnv != "":
+        return nonEmptyToken(os.Getenv(c.TokenEnv), "environment variable "+c.TokenEnv)
+    case c.Exec != nil:
+        return c.Exec.token()
+    case c.EncryptedToken != "":
+        passphrase, err := GetPassphrase(c.Name)
+        if err != nil {
+            return "", err
+        }
+        return DecryptToken(c.EncryptedToken, passphrase)
+    }
+    return c.Token, nil
+}
+
+// GetPassphrase returns the passphrase for encrypted tokens from
+// PassphraseEnv, or asks for it through PassphrasePrompt.
+func GetPassphrase(contextName string) (string, error) {
+    if p := os.Getenv(PassphraseEnv); p != "" {
+        return p, nil
+    }
+    if PassphrasePrompt == nil {
+        return "", fmt.Errorf("a passphrase is needed for the token of context '%s'; set %s", contextName, PassphraseEnv)
+    }
+    p, err := PassphrasePrompt(contextName)
+    if err != nil {
+        return "", err
+    }
+    if p == "" {
+        return "", fmt.Errorf("empty passphrase")
+    }
+    return p, nil
+}
+
+func (e *ExecCredential) token() (string, error) {
+    cmd := exec.Command(e.Command, e.Args...)
+    cmd.Env = append(os.Environ(), e.Env...)
+    var stdout bytes.Buffer
+    cmd.Stdout = &stdout
+    cmd.Stderr = os.Stderr
+    cmd.Stdin = os.Stdin
+    if err := cmd.Run(); err != nil {
+        return "", fmt.Errorf("credential command %s failed: %v", e.Command, err)
+    }
+    return nonEmptyToken(stdout.String(), "credential command "+e.Command)
+}
+
+func nonEmptyToken(token, source string) (string, error) {
+    token = strings.TrimSpace(token)
+    if token == "" {
+        return "", fmt.Errorf("%s is empty", source)
+    }
+    return token, nil
+}
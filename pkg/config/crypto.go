@@ -0,0 +1,133 @@
+package config
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/binary"
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// Encrypted tokens are stored as
+//
+//     pbkdf2-sha256-aes256gcm$<iterations>$<salt>$<nonce and ciphertext>
+//
+// with salt and ciphertext in unpadded base64. The key is derived from the
+// passphrase with PBKDF2-HMAC-SHA256 and the token sealed with AES-256-GCM.
+const (
+    encryptionScheme = "pbkdf2-sha256-aes256gcm"
+    // encryptionIterations follows current OWASP guidance for PBKDF2-SHA256.
+    encryptionIterations = 600000
+    saltSize             = 16
+    keySize              = 32
+)
+
+var encoding = base64.RawStdEncoding
+
+// EncryptToken encrypts token with a key derived from passphrase.
+func EncryptToken(token, passphrase string) (string, error) {
+    return encryptToken(token, passphrase, encryptionIterations)
+}
+
+func encryptToken(token, passphrase string, iterations int) (string, error) {
+    salt := make([]byte, saltSize)
+    if _, err := rand.Read(salt); err != nil {
+        return "", err
+    }
+    gcm, err := newGCM(passphrase, salt, iterations)
+    if err != nil {
+        return "", err
+    }
+    nonce := make([]byte, gcm.NonceSize())
+    if _, err := rand.Read(nonce); err != nil {
+        return "", err
+    }
+    sealed := gcm.Seal(nonce, nonce, []byte(token), nil)
+    return strings.Join([]string{
+        encryptionScheme,
+        strconv.Itoa(iterations),
+        encoding.EncodeToString(salt),
+        encoding.EncodeToString(sealed),
+    }, "$"), nil
+}
+
+// DecryptToken reverses EncryptToken.
+func DecryptToken(encrypted, passphrase string) (string, error) {
+    parts := strings.Split(encrypted, "$")
+    if len(parts) != 4 || parts[0] != encryptionScheme {
+        return "", fmt.Errorf("unsupported encrypted token format")
+    }
+    iterations, err := strconv.Atoi(parts[1])
+    if err != nil || iterations < 1 {
+        return "", fmt.Errorf("invalid encrypted token: bad iteration count")
+    }
+    salt, err := encoding.DecodeString(parts[2])
+    if err != nil {
+        return "", fmt.Errorf("invalid encrypted token: %v", err)
+    }
+    sealed, err := encoding.DecodeString(parts[3])
+    if err != nil {
+        return "", fmt.Errorf("invalid encrypted token: %v", err)
+    }
+
+    gcm, err := newGCM(passphrase, salt, iterations)
+    if err != nil {
+        return "", err
+    }
+    if len(sealed) < gcm.NonceSize() {
+        return "", fmt.Errorf("invalid encrypted token: too short")
+    }
+    nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+    token, err := gcm.Open(nil, nonce, ciphertext, nil)
+    if err != nil {
+        return "", fmt.Errorf("failed to decrypt token: wrong passphrase or corrupted data")
+    }
+    return string(token), nil
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+    key := pbkdf2SHA256([]byte(passphrase), salt, iterations, keySize)
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        return nil, err
+    }
+    return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 derives a key of keyLen bytes as specified by RFC 8018,
+// section 5.2, with HMAC-SHA256 as the pseudorandom function.
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+    prf := hmac.New(sha256.New, password)
+    hashLen := prf.Size()
+    blocks := (keyLen + hashLen - 1) / hashLen
+
+    var counter [4]byte
+    key := make([]byte, 0, blocks*hashLen)
+    u := make([]byte, hashLen)
+    for block := 1; block <= blocks; block++ {
+        // U1 = PRF(password, salt || INT(block))
+        prf.Reset()
+        prf.Write(salt)
+        binary.BigEndian.PutUint32(counter[:], uint32(block))
+        prf.Write(counter[:])
+        key = prf.Sum(key)
+        t := key[len(key)-hashLen:]
+        copy(u, t)
+
+        // T = U1 ^ U2 ^ ... ^ Uc, with Un = PRF(password, Un-1)
+        for n := 2; n <= iterations; n++ {
+            prf.Reset()
+            prf.Write(u)
+            u = prf.Sum(u[:0])
+            for i := range u {
+                t[i] ^= u[i]
+            }
+        }
+    }
+    return key[:keyLen]
+}
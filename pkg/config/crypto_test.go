@@ -0,0 +1,75 @@
+package config
+
+import (
+    "encoding/hex"
+    "strings"
+    "testing"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+    // Test vectors for PBKDF2-HMAC-SHA256, as published alongside RFC 7914.
+    tests := []struct {
+        password, salt string
+        iterations     int
+        keyLen         int
+        want           string
+    }{
+        {"password", "salt", 1, 32, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+        {"password", "salt", 2, 32, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+        {"password", "salt", 4096, 32, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+        {"passwd", "salt", 1, 64, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+    }
+    for _, tt := range tests {
+        got := hex.EncodeToString(pbkdf2SHA256([]byte(tt.password), []byte(tt.salt), tt.iterations, tt.keyLen))
+        if got != tt.want {
+            t.Errorf("pbkdf2SHA256(%q, %q, %d, %d) = %s, want %s", tt.password, tt.salt, tt.iterations, tt.keyLen, got, tt.want)
+        }
+    }
+}
+
+func TestEncryptToken(t *testing.T) {
+    encrypted, err := encryptToken("odt_secret", "correct horse", 1000)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if strings.Contains(encrypted, "odt_secret") {
+        t.Fatalf("encrypted token contains the plaintext: %s", encrypted)
+    }
+    if !strings.HasPrefix(encrypted, encryptionScheme+"$1000$") {
+        t.Fatalf("unexpected format: %s", encrypted)
+    }
+
+    token, err := DecryptToken(encrypted, "correct horse")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if token != "odt_secret" {
+        t.Errorf("DecryptToken = %q, want %q", token, "odt_secret")
+    }
+
+    if _, err := DecryptToken(encrypted, "battery staple"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+        t.Errorf("DecryptToken with wrong passphrase: err = %v", err)
+    }
+
+    again, err := encryptToken("odt_secret", "correct horse", 1000)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if again == encrypted {
+        t.Error("encrypting twice gave the same result; salt and nonce must be random")
+    }
+}
+
+func TestDecryptTokenInvalid(t *testing.T) {
+    for _, encrypted := range []string{
+        "odt_secret",
+        "aes$1$c2FsdA$c2VhbGVk",
+        encryptionScheme + "$0$c2FsdA$c2VhbGVk",
+        encryptionScheme + "$1$!!$c2VhbGVk",
+        encryptionScheme + "$1$c2FsdA$c2Vh",
+    } {
+        if _, err := DecryptToken(encrypted, "pass"); err == nil {
+            t.Errorf("DecryptToken(%q) succeeded", encrypted)
+        }
+    }
+}
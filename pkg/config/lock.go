@@ -0,0 +1,26 @@
+package config
+
+import (
+    "fmt"
+    "os"
+)
+
+// lockConfig takes an exclusive advisory lock for the config file at path,
+// waiting for other dtctl processes to release it. The lock is held on a
+// separate lock file, since the config file itself is replaced on every
+// write. The lock file is left in place; removing it would let two
+// processes lock different files.
+func lockConfig(path string) (unlock func(), err error) {
+    f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+    if err != nil {
+        return nil, fmt.Errorf("failed to lock config file: %v", err)
+    }
+    if err := lockFile(f); err != nil {
+        f.Close()
+        return nil, fmt.Errorf("failed to lock config file: %v", err)
+    }
+    return func() {
+        unlockFile(f)
+        f.Close()
+    }, nil
+}
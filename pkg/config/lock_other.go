@@ -0,0 +1,12 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package config
+
+import "os"
+
+// Platforms without flock or LockFileEx get no locking; writes are still
+// atomic.
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
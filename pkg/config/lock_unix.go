@@ -0,0 +1,22 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package config
+
+import (
+    "os"
+    "syscall"
+)
+
+func lockFile(f *os.File) error {
+    for {
+        err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+        if err != syscall.EINTR {
+            return err
+        }
+    }
+}
+
+func unlockFile(f *os.File) error {
+    return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,38 @@
+//go:build windows
+// +build windows
+
+package config
+
+import (
+    "os"
+    "syscall"
+    "unsafe"
+)
+
+var (
+    kernel32         = syscall.NewLazyDLL("kernel32.dll")
+    procLockFileEx   = kernel32.NewProc("LockFileEx")
+    procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const lockfileExclusiveLock = 0x2
+
+// lockFile and unlockFile lock the first byte of f, which is enough for an
+// advisory lock as long as every dtctl locks the same range.
+func lockFile(f *os.File) error {
+    var ol syscall.Overlapped
+    r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+    if r == 0 {
+        return err
+    }
+    return nil
+}
+
+func unlockFile(f *os.File) error {
+    var ol syscall.Overlapped
+    r, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+    if r == 0 {
+        return err
+    }
+    return nil
+}
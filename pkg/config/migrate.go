@@ -0,0 +1,76 @@
+package config
+
+import (
+    "encoding/json"
+    "fmt"
+)
+
+// APIVersion is the config file schema written by this version of dtctl.
+const APIVersion = "dtctl/v1"
+
+// legacyAPIVersion stands for config files written before apiVersion was
+// introduced.
+const legacyAPIVersion = ""
+
+// A migration rewrites a config file of version from into version to. It
+// works on the decoded JSON rather than on Config, so it can handle keys
+// that Config no longer has.
+type migration struct {
+    from, to string
+    migrate  func(raw map[string]interface{}) error
+}
+
+// migrations lists the schema changes in order. A schema change adds a new
+// APIVersion and a migration from the previous one.
+var migrations = []migration{
+    // Version 1 only adds apiVersion itself.
+    {from: legacyAPIVersion, to: "dtctl/v1", migrate: func(map[string]interface{}) error { return nil }},
+}
+
+// decodeConfig parses a config file, migrating it to APIVersion first.
+func decodeConfig(data []byte) (*Config, error) {
+    var raw map[string]interface{}
+    if err := json.Unmarshal(data, &raw); err != nil {
+        return nil, err
+    }
+    if err := migrate(raw); err != nil {
+        return nil, err
+    }
+    data, err := json.Marshal(raw)
+    if err != nil {
+        return nil, err
+    }
+    var cfg Config
+    if err := json.Unmarshal(data, &cfg); err != nil {
+        return nil, err
+    }
+    return &cfg, nil
+}
+
+func migrate(raw map[string]interface{}) error {
+    version := legacyAPIVersion
+    if v, ok := raw["apiVersion"]; ok {
+        s, ok := v.(string)
+        if !ok {
+            return fmt.Errorf("apiVersion must be a string, got %v", v)
+        }
+        version = s
+    }
+    for _, m := range migrations {
+        if version == APIVersion {
+            break
+        }
+        if m.from != version {
+            continue
+        }
+        if err := m.migrate(raw); err != nil {
+            return fmt.Errorf("failed to migrate from apiVersion %q to %q: %v", m.from, m.to, err)
+        }
+        version = m.to
+        raw["apiVersion"] = version
+    }
+    if version != APIVersion {
+        return fmt.Errorf("unsupported apiVersion %q; this dtctl supports %q, a newer dtctl may be needed", version, APIVersion)
+    }
+    return nil
+}
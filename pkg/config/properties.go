@@ -0,0 +1,197 @@
+package config
+
+import (
+    "encoding/base64"
+    "fmt"
+    "reflect"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// SetProperty sets a single value in the config file. path is either
+// current_context or contexts.NAME.PROPERTY, where PROPERTY is a key of the
+// context as written in the config file, e.g. url, max_retries or
+// exec.command. value is parsed according to the property's type: booleans
+// and numbers as usual, *_data properties as base64 and lists such as
+// exec.args as comma-separated values.
+func SetProperty(path, value string) error {
+    return updateConfig(func(cfg *Config) error {
+        return setProperty(cfg, path, &value)
+    })
+}
+
+// UnsetProperty clears a single value in the config file, addressed like in
+// SetProperty. Unsetting exec removes the exec credential as a whole.
+func UnsetProperty(path string) error {
+    return updateConfig(func(cfg *Config) error {
+        return setProperty(cfg, path, nil)
+    })
+}
+
+// setProperty sets the property at path to value, or clears it when value
+// is nil.
+func setProperty(cfg *Config, path string, value *string) error {
+    if path == "current_context" {
+        if value == nil {
+            cfg.CurrentContext = ""
+            return nil
+        }
+        if cfg.context(*value) == nil {
+            return fmt.Errorf("context '%s' not found", *value)
+        }
+        cfg.CurrentContext = *value
+        return nil
+    }
+
+    rest := strings.TrimPrefix(path, "contexts.")
+    if rest == path {
+        return fmt.Errorf("invalid property path %q; expected current_context or contexts.NAME.PROPERTY", path)
+    }
+    // Context names may contain dots, so the longest name that prefixes
+    // the rest of the path wins.
+    var ctx *Context
+    for i := range cfg.Contexts {
+        c := &cfg.Contexts[i]
+        if strings.HasPrefix(rest, c.Name+".") && (ctx == nil || len(c.Name) > len(ctx.Name)) {
+            ctx = c
+        }
+    }
+    if ctx == nil {
+        return fmt.Errorf("no context found for property path %q", path)
+    }
+    property := strings.TrimPrefix(rest, ctx.Name+".")
+    if property == "name" {
+        return fmt.Errorf("use 'dtctl config rename-context' to rename a context")
+    }
+
+    updated := *ctx
+    if updated.Exec != nil {
+        exec := *updated.Exec
+        updated.Exec = &exec
+    }
+    if err := setField(reflect.ValueOf(&updated).Elem(), strings.Split(property, "."), value); err != nil {
+        return fmt.Errorf("invalid property path %q: %v", path, err)
+    }
+    if err := updated.validate(); err != nil {
+        return err
+    }
+    *ctx = updated
+    return nil
+}
+
+// validate checks the consistency rules that SetProperty could break.
+func (c *Context) validate() error {
+    if c.Exec != nil && c.Exec.Command == "" {
+        return fmt.Errorf("context '%s' would have an exec credential without a command", c.Name)
+    }
+    if sources := c.tokenSources(); len(sources) > 1 {
+        return fmt.Errorf("context '%s' would have more than one token source: %s; unset the others first", c.Name, strings.Join(sources, ", "))
+    }
+    return nil
+}
+
+// setField sets the field of the struct v named by the JSON keys in path.
+func setField(v reflect.Value, path []string, value *string) error {
+    field, ok := fieldByKey(v, path[0])
+    if !ok {
+        return fmt.Errorf("unknown property %q; known properties are %s", path[0], strings.Join(fieldKeys(v.Type()), ", "))
+    }
+    if len(path) > 1 {
+        if field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct {
+            if field.IsNil() {
+                if value == nil {
+                    return nil
+                }
+                field.Set(reflect.New(field.Type().Elem()))
+            }
+            field = field.Elem()
+        }
+        if field.Kind() != reflect.Struct {
+            return fmt.Errorf("property %q has no properties", path[0])
+        }
+        return setField(field, path[1:], value)
+    }
+
+    if value == nil {
+        field.Set(reflect.Zero(field.Type()))
+        return nil
+    }
+    parsed, err := parseValue(field.Type(), *value)
+    if err != nil {
+        return fmt.Errorf("invalid value for %s: %v", path[0], err)
+    }
+    field.Set(parsed)
+    return nil
+}
+
+func parseValue(t reflect.Type, s string) (reflect.Value, error) {
+    switch {
+    case t.Kind() == reflect.String:
+        return reflect.ValueOf(s).Convert(t), nil
+    case t.Kind() == reflect.Bool:
+        b, err := strconv.ParseBool(s)
+        if err != nil {
+            return reflect.Value{}, fmt.Errorf("expected true or false, got %q", s)
+        }
+        return reflect.ValueOf(b), nil
+    case t.Kind() == reflect.Int:
+        n, err := strconv.Atoi(s)
+        if err != nil {
+            return reflect.Value{}, fmt.Errorf("expected an integer, got %q", s)
+        }
+        return reflect.ValueOf(n), nil
+    case t.Kind() == reflect.Ptr:
+        elem, err := parseValue(t.Elem(), s)
+        if err != nil {
+            return reflect.Value{}, err
+        }
+        p := reflect.New(t.Elem())
+        p.Elem().Set(elem)
+        return p, nil
+    case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+        data, err := base64.StdEncoding.DecodeString(s)
+        if err != nil {
+            return reflect.Value{}, fmt.Errorf("expected base64: %v", err)
+        }
+        return reflect.ValueOf(data), nil
+    case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+        if s == "" {
+            return reflect.Zero(t), nil
+        }
+        return reflect.ValueOf(strings.Split(s, ",")), nil
+    case t.Kind() == reflect.Struct:
+        return reflect.Value{}, fmt.Errorf("set its properties %s instead", strings.Join(fieldKeys(t), ", "))
+    }
+    return reflect.Value{}, fmt.Errorf("unsupported type %s", t)
+}
+
+// fieldByKey returns the field of the struct v with the given JSON key.
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+    t := v.Type()
+    for i := 0; i < t.NumField(); i++ {
+        if jsonKey(t.Field(i)) == key {
+            return v.Field(i), true
+        }
+    }
+    return reflect.Value{}, false
+}
+
+func fieldKeys(t reflect.Type) []string {
+    var keys []string
+    for i := 0; i < t.NumField(); i++ {
+        if key := jsonKey(t.Field(i)); key != "" && key != "name" {
+            keys = append(keys, key)
+        }
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+func jsonKey(f reflect.StructField) string {
+    tag := strings.Split(f.Tag.Get("json"), ",")[0]
+    if tag == "-" {
+        return ""
+    }
+    return tag
+}
@@ -0,0 +1,32 @@
+package dependencytrack
+
+import "context"
+
+// API is the part of the Dependency-Track REST API that dtctl uses. *Client
+// implements it against a real server; tests and tools embedding the dtctl
+// commands can substitute their own implementation.
+type API interface {
+    GetVersionContext(ctx context.Context) (*About, error)
+    GetCurrentTeamContext(ctx context.Context) (*Team, error)
+
+    GetProjectsContext(ctx context.Context) ([]Project, error)
+    WalkProjectsContext(ctx context.Context, fn func([]Project) error) error
+    GetProjectsByTagContext(ctx context.Context, tag string) ([]Project, error)
+    WalkProjectsByTagContext(ctx context.Context, tag string, fn func([]Project) error) error
+
+    GetComponentsByProjectUUIDContext(ctx context.Context, projectUUID string) ([]Component, error)
+    WalkComponentsByProjectUUIDContext(ctx context.Context, projectUUID string, fn func([]Component) error) error
+    GetComponentByUUIDContext(ctx context.Context, componentUUID string) (*Component, error)
+    GetComponentVulnerabilitiesContext(ctx context.Context, componentUUID string, suppressed bool) ([]Vulnerability, error)
+    GetProjectVulnerabilitiesContext(ctx context.Context, projectUUID string, suppressed bool) ([]Vulnerability, error)
+    GetProjectFindingsContext(ctx context.Context, projectUUID string, suppressed bool) ([]Finding, error)
+    UpdateComponentSHA256Context(ctx context.Context, componentUUID, newSHA256 string) error
+
+    GetPoliciesContext(ctx context.Context) ([]Policy, error)
+    WalkPoliciesContext(ctx context.Context, fn func([]Policy) error) error
+    GetPolicyByUUIDContext(ctx context.Context, policyUUID string) (*Policy, error)
+    UpdatePolicyConditionContext(ctx context.Context, condition PolicyCondition) error
+    GetLicenseGroupContext(ctx context.Context, groupUUID string) (*LicenseGroup, error)
+}
+
+var _ API = (*Client)(nil)
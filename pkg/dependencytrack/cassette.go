@@ -0,0 +1,234 @@
+package dependencytrack
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "net/http"
+    "net/url"
+    "strings"
+    "sync"
+)
+
+// redacted replaces the value of sensitive headers in cassettes.
+const redacted = "REDACTED"
+
+// sensitiveHeaders are never written to a cassette as they are.
+var sensitiveHeaders = []string{"X-Api-Key", "Authorization", "Cookie", "Set-Cookie"}
+
+// Cassette is a recorded sequence of HTTP exchanges with a server.
+type Cassette struct {
+    Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and the response it got.
+type Interaction struct {
+    Request  RecordedRequest  `json:"request"`
+    Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request as stored in a cassette.
+type RecordedRequest struct {
+    Method string      `json:"method"`
+    URL    string      `json:"url"`
+    Header http.Header `json:"header,omitempty"`
+    Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response as stored in a cassette.
+type RecordedResponse struct {
+    StatusCode int         `json:"statusCode"`
+    Status     string      `json:"status"`
+    Header     http.Header `json:"header,omitempty"`
+    Body       string      `json:"body,omitempty"`
+}
+
+// LoadCassette reads a cassette written by a Recorder.
+func LoadCassette(path string) (*Cassette, error) {
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read cassette: %v", err)
+    }
+    var c Cassette
+    if err := json.Unmarshal(data, &c); err != nil {
+        return nil, fmt.Errorf("failed to parse cassette %s: %v", path, err)
+    }
+    return &c, nil
+}
+
+// Save writes the cassette to path.
+func (c *Cassette) Save(path string) error {
+    // Keep URLs and bodies readable: no \u0026 for every query string.
+    var buf bytes.Buffer
+    enc := json.NewEncoder(&buf)
+    enc.SetEscapeHTML(false)
+    enc.SetIndent("", "  ")
+    if err := enc.Encode(c); err != nil {
+        return err
+    }
+    if err := ioutil.WriteFile(path, buf.Bytes(), 0600); err != nil {
+        return fmt.Errorf("failed to write cassette: %v", err)
+    }
+    return nil
+}
+
+// Recorder is an http.RoundTripper that passes requests on to Next and
+// records every exchange to a cassette file, with credentials redacted. The
+// file is rewritten after each exchange so it stays complete even if the
+// process is interrupted.
+type Recorder struct {
+    // Next performs the requests; nil means http.DefaultTransport.
+    Next http.RoundTripper
+
+    path     string
+    mu       sync.Mutex
+    cassette Cassette
+}
+
+// NewRecorder returns a Recorder writing to path, wrapping next.
+func NewRecorder(path string, next http.RoundTripper) *Recorder {
+    return &Recorder{Next: next, path: path}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+    reqBody, err := requestBody(req)
+    if err != nil {
+        return nil, err
+    }
+
+    next := r.Next
+    if next == nil {
+        next = http.DefaultTransport
+    }
+    resp, err := next.RoundTrip(req)
+    if err != nil {
+        return nil, err
+    }
+    respBody, err := ioutil.ReadAll(resp.Body)
+    resp.Body.Close()
+    if err != nil {
+        return nil, err
+    }
+    resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+        Request: RecordedRequest{
+            Method: req.Method,
+            URL:    req.URL.String(),
+            Header: redactHeader(req.Header),
+            Body:   string(reqBody),
+        },
+        Response: RecordedResponse{
+            StatusCode: resp.StatusCode,
+            Status:     resp.Status,
+            Header:     redactHeader(resp.Header),
+            Body:       string(respBody),
+        },
+    })
+    if err := r.cassette.Save(r.path); err != nil {
+        resp.Body.Close()
+        return nil, err
+    }
+    return resp, nil
+}
+
+// Replayer is an http.RoundTripper that answers requests from a cassette
+// instead of the network. A request is matched to the first unused
+// interaction with the same method, path, query and body; the server's host
+// is ignored so cassettes can be replayed against any base URL.
+type Replayer struct {
+    mu       sync.Mutex
+    cassette *Cassette
+    used     []bool
+}
+
+// NewReplayer returns a Replayer serving the cassette at path.
+func NewReplayer(path string) (*Replayer, error) {
+    c, err := LoadCassette(path)
+    if err != nil {
+        return nil, err
+    }
+    return &Replayer{cassette: c, used: make([]bool, len(c.Interactions))}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+    body, err := requestBody(req)
+    if err != nil {
+        return nil, err
+    }
+    target := req.URL.RequestURI()
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for i, in := range r.cassette.Interactions {
+        if r.used[i] || in.Request.Method != req.Method || in.Request.Body != string(body) {
+            continue
+        }
+        if requestURI(in.Request.URL) != target {
+            continue
+        }
+        r.used[i] = true
+        return &http.Response{
+            StatusCode:    in.Response.StatusCode,
+            Status:        in.Response.Status,
+            Proto:         "HTTP/1.1",
+            ProtoMajor:    1,
+            ProtoMinor:    1,
+            Header:        in.Response.Header.Clone(),
+            Body:          ioutil.NopCloser(strings.NewReader(in.Response.Body)),
+            ContentLength: int64(len(in.Response.Body)),
+            Request:       req,
+        }, nil
+    }
+    return nil, fmt.Errorf("no recorded response for %s %s", req.Method, target)
+}
+
+// requestBody reads the body of req without consuming it.
+func requestBody(req *http.Request) ([]byte, error) {
+    if req.Body == nil || req.Body == http.NoBody {
+        return nil, nil
+    }
+    if req.GetBody != nil {
+        body, err := req.GetBody()
+        if err != nil {
+            return nil, err
+        }
+        defer body.Close()
+        return ioutil.ReadAll(body)
+    }
+    data, err := ioutil.ReadAll(req.Body)
+    req.Body.Close()
+    if err != nil {
+        return nil, err
+    }
+    req.Body = ioutil.NopCloser(bytes.NewReader(data))
+    return data, nil
+}
+
+// requestURI returns the path and query of a recorded URL.
+func requestURI(rawURL string) string {
+    u, err := url.Parse(rawURL)
+    if err != nil {
+        return rawURL
+    }
+    return u.RequestURI()
+}
+
+// redactHeader returns a copy of h with credentials replaced.
+func redactHeader(h http.Header) http.Header {
+    if len(h) == 0 {
+        return nil
+    }
+    out := h.Clone()
+    for _, name := range sensitiveHeaders {
+        if _, ok := out[name]; ok {
+            out.Set(name, redacted)
+        }
+    }
+    return out
+}
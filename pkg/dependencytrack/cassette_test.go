@@ -0,0 +1,62 @@
+package dependencytrack_test
+
+import (
+    "io/ioutil"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+
+    "dtctl/pkg/dependencytrack"
+    "dtctl/pkg/dependencytrack/fake"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+    srv := fake.NewServer(fake.Fixtures{
+        Projects: []dependencytrack.Project{{Name: "one", UUID: "p1"}, {Name: "two", UUID: "p2"}},
+        Components: []dependencytrack.Component{
+            {UUID: "c1", Name: "lib", Project: dependencytrack.ProjectReference{UUID: "p1"}},
+        },
+    })
+    path := filepath.Join(t.TempDir(), "cassette.json")
+
+    client := srv.Client()
+    client.HTTPClient.Transport = dependencytrack.NewRecorder(path, nil)
+    projects, err := client.GetProjects()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := client.UpdateComponentSHA256("c1", "abc"); err != nil {
+        t.Fatal(err)
+    }
+    srv.Close()
+
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if strings.Contains(string(data), fake.Token) {
+        t.Errorf("cassette contains the API key:\n%s", data)
+    }
+
+    replayer, err := dependencytrack.NewReplayer(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    offline := dependencytrack.NewClient("http://replay.invalid", "")
+    offline.HTTPClient.Transport = replayer
+    offline.Retry.MaxRetries = 0
+    replayed, err := offline.GetProjects()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(replayed, projects) {
+        t.Errorf("replayed %+v, recorded %+v", replayed, projects)
+    }
+    if err := offline.UpdateComponentSHA256("c1", "abc"); err != nil {
+        t.Fatal(err)
+    }
+    if err := offline.UpdateComponentSHA256("c1", "abc"); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+        t.Errorf("got %v, want an error for a request missing from the cassette", err)
+    }
+}
@@ -2,18 +2,39 @@ package dependencytrack
 
 import (
     "bytes"
+    "context"
     "encoding/json"
+    "errors"
     "fmt"
+    "io"
+    "io/ioutil"
     "net/http"
     "net/url"
+    "strconv"
     "strings"
+    "time"
 )
 
+// DefaultTimeout is the per-request timeout used by NewClient.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultPageSize is the number of items requested per page by list calls.
+const DefaultPageSize = 100
+
+// ErrStopWalk can be returned by a Walk callback to stop paging without
+// reporting an error.
+var ErrStopWalk = errors.New("stop walk")
+
 // Client represents a Dependency-Track API client.
 type Client struct {
     BaseURL    string
     APIToken   string
     HTTPClient *http.Client
+    // PageSize is the number of items requested per page by list calls.
+    // Zero or less means DefaultPageSize.
+    PageSize int
+    // Retry controls retrying of transient failures.
+    Retry RetryPolicy
 }
 
 // NewClient initializes and returns a new Client.
@@ -21,133 +42,407 @@ func NewClient(baseURL, apiToken string) *Client {
     return &Client{
         BaseURL:    strings.TrimRight(baseURL, "/"),
         APIToken:   apiToken,
-        HTTPClient: &http.Client{},
+        HTTPClient: &http.Client{Timeout: DefaultTimeout},
+        PageSize:   DefaultPageSize,
+        Retry:      DefaultRetryPolicy(),
+    }
+}
+
+// newRequest builds an authenticated request bound to ctx.
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+    req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("X-Api-Key", c.APIToken)
+    return req, nil
+}
+
+// list requests every page of a list endpoint in turn. decode is called with
+// the body of each page and returns how many items it read. Paging stops once
+// the X-Total-Count reported by the server is reached, a short page is
+// returned, or decode fails; ErrStopWalk from decode ends paging cleanly.
+// Servers that ignore paging are caught by the page being larger than
+// requested without an X-Total-Count, or by a page repeating the previous
+// one, which is not decoded again.
+func (c *Client) list(ctx context.Context, op, endpoint string, decode func(body io.Reader) (int, error)) error {
+    pageSize := c.PageSize
+    if pageSize <= 0 {
+        pageSize = DefaultPageSize
+    }
+
+    seen := 0
+    var previous []byte
+    for pageNumber := 1; ; pageNumber++ {
+        u, err := url.Parse(endpoint)
+        if err != nil {
+            return err
+        }
+        query := u.Query()
+        query.Set("pageNumber", strconv.Itoa(pageNumber))
+        query.Set("pageSize", strconv.Itoa(pageSize))
+        u.RawQuery = query.Encode()
+
+        req, err := c.newRequest(ctx, "GET", u.String(), nil)
+        if err != nil {
+            return err
+        }
+        resp, err := c.do(req)
+        if err != nil {
+            return err
+        }
+        if resp.StatusCode != http.StatusOK {
+            apiErr := newAPIError(op, resp)
+            resp.Body.Close()
+            return apiErr
+        }
+
+        body, err := ioutil.ReadAll(resp.Body)
+        resp.Body.Close()
+        if err != nil {
+            return err
+        }
+        if previous != nil && bytes.Equal(body, previous) {
+            return nil
+        }
+        previous = body
+
+        n, err := decode(bytes.NewReader(body))
+        if errors.Is(err, ErrStopWalk) {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        seen += n
+
+        total, err := strconv.Atoi(resp.Header.Get("X-Total-Count"))
+        if err == nil && seen >= total {
+            return nil
+        }
+        if n < pageSize || (err != nil && n > pageSize) {
+            return nil
+        }
     }
 }
 
 // Project represents a project in Dependency-Track.
 type Project struct {
-    Name string `json:"name"`
-    UUID string `json:"uuid"`
+    Name    string `json:"name"`
+    UUID    string `json:"uuid"`
+    Version string `json:"version,omitempty"`
+    Tags    []Tag  `json:"tags,omitempty"`
+    // Parent is the project this one is a child of, if any.
+    Parent *ProjectReference `json:"parent,omitempty"`
+    // IsLatest marks the latest version of a project. Servers before 4.12
+    // do not send it.
+    IsLatest bool `json:"isLatest,omitempty"`
     // Remove Sha256 unless it's needed
 }
 
-// ProjectReference represents the project associated with a component.
+// Tag represents a tag attached to a project or policy.
+type Tag struct {
+    Name string `json:"name"`
+}
+
+// ProjectReference refers to a project, e.g. the project of a component or
+// the parent of a project.
 type ProjectReference struct {
     UUID string `json:"uuid"`
+    Name string `json:"name,omitempty"`
 }
 
 // Component represents a component in Dependency-Track.
 type Component struct {
-    UUID     string           `json:"uuid"`
-    Name     string           `json:"name"`
-    Sha256   string           `json:"sha256"`
-    Sha1     string           `json:"sha1"`
-    Md5      string           `json:"md5"`
-    Project  ProjectReference `json:"project"`
+    UUID    string `json:"uuid"`
+    Group   string `json:"group,omitempty"`
+    Name    string `json:"name"`
+    Version string `json:"version,omitempty"`
+    PURL    string `json:"purl,omitempty"`
+    // The hashes Dependency-Track stores; see Hash.
+    Sha256     string `json:"sha256,omitempty"`
+    Sha1       string `json:"sha1,omitempty"`
+    Md5        string `json:"md5,omitempty"`
+    Sha384     string `json:"sha384,omitempty"`
+    Sha512     string `json:"sha512,omitempty"`
+    Sha3256    string `json:"sha3_256,omitempty"`
+    Sha3384    string `json:"sha3_384,omitempty"`
+    Sha3512    string `json:"sha3_512,omitempty"`
+    Blake2b256 string `json:"blake2b_256,omitempty"`
+    Blake2b384 string `json:"blake2b_384,omitempty"`
+    Blake2b512 string `json:"blake2b_512,omitempty"`
+    Blake3     string `json:"blake3,omitempty"`
+    CPE        string `json:"cpe,omitempty"`
+    SwidTagID  string `json:"swidTagId,omitempty"`
+    // License is the license as declared by the component; ResolvedLicense
+    // is set when Dependency-Track recognized it.
+    License         string           `json:"license,omitempty"`
+    ResolvedLicense *License         `json:"resolvedLicense,omitempty"`
+    RepositoryMeta  *RepositoryMeta  `json:"repositoryMeta,omitempty"`
+    Project         ProjectReference `json:"project"`
     // Add other fields if necessary
 }
 
+// RepositoryMeta is what Dependency-Track learned about a component from its
+// package repository.
+type RepositoryMeta struct {
+    LatestVersion string `json:"latestVersion,omitempty"`
+    // Published is when the component's version was published, in
+    // milliseconds since the epoch; zero when unknown.
+    Published int64 `json:"published,omitempty"`
+}
+
+// PublishedAt returns when the component's version was published, and false
+// when the repository did not tell.
+func (c *Component) PublishedAt() (time.Time, bool) {
+    if c.RepositoryMeta == nil || c.RepositoryMeta.Published == 0 {
+        return time.Time{}, false
+    }
+    return time.Unix(0, c.RepositoryMeta.Published*int64(time.Millisecond)).UTC(), true
+}
+
 // Policy represents a policy in Dependency-Track.
 type Policy struct {
-    Name     string    `json:"name"`
-    UUID     string    `json:"uuid"`
+    Name string `json:"name"`
+    UUID string `json:"uuid"`
+    // Operator is ANY or ALL: whether one or every condition must match.
+    Operator string `json:"operator,omitempty"`
+    // ViolationState is INFO, WARN or FAIL.
+    ViolationState   string            `json:"violationState,omitempty"`
+    PolicyConditions []PolicyCondition `json:"policyConditions,omitempty"`
+    // Projects and Tags limit the policy's scope; a policy with neither
+    // applies to every project.
     Projects []Project `json:"projects,omitempty"`
-    // Add other fields if necessary
+    Tags     []Tag     `json:"tags,omitempty"`
+    // IncludeChildren extends the scope to children of the listed projects.
+    IncludeChildren bool `json:"includeChildren"`
+    // OnlyLatestProjectVersion restricts the scope to the latest version of
+    // each project.
+    OnlyLatestProjectVersion bool `json:"onlyLatestProjectVersion"`
 }
 
+// Policy condition subjects and operators used by dtctl.
+const (
+    SubjectAge             = "AGE"
+    SubjectComponentHash   = "COMPONENT_HASH"
+    SubjectCoordinates     = "COORDINATES"
+    SubjectCPE             = "CPE"
+    SubjectCWE             = "CWE"
+    SubjectEPSS            = "EPSS"
+    SubjectLicense         = "LICENSE"
+    SubjectLicenseGroup    = "LICENSE_GROUP"
+    SubjectPackageURL      = "PACKAGE_URL"
+    SubjectSeverity        = "SEVERITY"
+    SubjectSWIDTagID       = "SWID_TAGID"
+    SubjectVersion         = "VERSION"
+    SubjectVulnerabilityID = "VULNERABILITY_ID"
+
+    OperatorIs                        = "IS"
+    OperatorIsNot                     = "IS_NOT"
+    OperatorMatches                   = "MATCHES"
+    OperatorNoMatch                   = "NO_MATCH"
+    OperatorContainsAny               = "CONTAINS_ANY"
+    OperatorContainsAll               = "CONTAINS_ALL"
+    OperatorNumericGreaterThan        = "NUMERIC_GREATER_THAN"
+    OperatorNumericLessThan           = "NUMERIC_LESS_THAN"
+    OperatorNumericEqual              = "NUMERIC_EQUAL"
+    OperatorNumericNotEqual           = "NUMERIC_NOT_EQUAL"
+    OperatorNumericGreaterThanOrEqual = "NUMERIC_GREATER_THAN_OR_EQUAL"
+    // Dependency-Track spells this one LESSER.
+    OperatorNumericLessThanOrEqual = "NUMERIC_LESSER_THAN_OR_EQUAL"
+)
+
 // PolicyCondition represents a policy condition in Dependency-Track.
 type PolicyCondition struct {
-    Operator string `json:"operator"`
-    Subject  string `json:"subject"`
-    Value    string `json:"value"`
-    UUID     string `json:"uuid"`
+    Operator      string `json:"operator"`
+    Subject       string `json:"subject"`
+    Value         string `json:"value"`
+    UUID          string `json:"uuid"`
+    ViolationType string `json:"violationType,omitempty"`
+    // Hash is the parsed Value of a COMPONENT_HASH condition. It is nil for
+    // other subjects and when Value does not hold a valid hash document.
+    Hash *HashValue `json:"hash,omitempty"`
+    // LicenseGroup is the group a LICENSE_GROUP condition refers to by
+    // UUID. Dependency-Track does not send it with the policy; it is nil
+    // until fetched with GetLicenseGroup.
+    LicenseGroup *LicenseGroup `json:"licenseGroup,omitempty"`
+}
+
+// HashValue is the value of a COMPONENT_HASH condition, which
+// Dependency-Track stores as a JSON document in the condition's value.
+type HashValue struct {
+    Algorithm string `json:"algorithm"`
+    Value     string `json:"value"`
+}
+
+// ParseHashValue parses the value of a COMPONENT_HASH condition.
+func ParseHashValue(value string) (*HashValue, error) {
+    var h HashValue
+    if err := json.Unmarshal([]byte(value), &h); err != nil {
+        return nil, fmt.Errorf("invalid hash condition value %q: %v", value, err)
+    }
+    return &h, nil
+}
+
+// Encode returns h in the form stored in a condition's value.
+func (h HashValue) Encode() string {
+    data, _ := json.Marshal(h)
+    return string(data)
+}
+
+// UnmarshalJSON decodes a condition and parses its hash value, if any.
+func (pc *PolicyCondition) UnmarshalJSON(data []byte) error {
+    type plain PolicyCondition
+    var p plain
+    if err := json.Unmarshal(data, &p); err != nil {
+        return err
+    }
+    *pc = PolicyCondition(p)
+    pc.Hash = nil
+    if pc.Subject == SubjectComponentHash {
+        if h, err := ParseHashValue(pc.Value); err == nil {
+            pc.Hash = h
+        }
+    }
+    return nil
 }
 
 // GetProjects fetches all projects from the Dependency-Track server.
 func (c *Client) GetProjects() ([]Project, error) {
-    req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/project", c.BaseURL), nil)
-    if err != nil {
-        return nil, err
-    }
-    req.Header.Set("X-Api-Key", c.APIToken)
-    resp, err := c.HTTPClient.Do(req)
+    return c.GetProjectsContext(context.Background())
+}
+
+// GetProjectsContext is like GetProjects but uses ctx for cancellation.
+func (c *Client) GetProjectsContext(ctx context.Context) ([]Project, error) {
+    var all []Project
+    err := c.WalkProjectsContext(ctx, func(page []Project) error {
+        all = append(all, page...)
+        return nil
+    })
     if err != nil {
         return nil, err
     }
-    defer resp.Body.Close()
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("failed to get projects: %s", resp.Status)
-    }
-    var projects []Project
-    if err := json.NewDecoder(resp.Body).Decode(&projects); err != nil {
-        return nil, err
-    }
-    return projects, nil
+    return all, nil
+}
+
+// WalkProjects calls fn with each page of projects as it is fetched, so large
+// portfolios can be processed without holding them all in memory.
+func (c *Client) WalkProjects(fn func([]Project) error) error {
+    return c.WalkProjectsContext(context.Background(), fn)
+}
+
+// WalkProjectsContext is like WalkProjects but uses ctx for cancellation.
+func (c *Client) WalkProjectsContext(ctx context.Context, fn func([]Project) error) error {
+    endpoint := fmt.Sprintf("%s/api/v1/project", c.BaseURL)
+    return c.list(ctx, "get projects", endpoint, func(body io.Reader) (int, error) {
+        var page []Project
+        if err := json.NewDecoder(body).Decode(&page); err != nil {
+            return 0, err
+        }
+        if len(page) == 0 {
+            return 0, nil
+        }
+        return len(page), fn(page)
+    })
 }
 
 // GetProjectsByTag fetches projects filtered by a specific tag.
 func (c *Client) GetProjectsByTag(tag string) ([]Project, error) {
-    // URL-encode the tag to handle special characters
-    encodedTag := url.PathEscape(tag)
-    req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/project/tag/%s", c.BaseURL, encodedTag), nil)
-    if err != nil {
-        return nil, err
-    }
-    req.Header.Set("X-Api-Key", c.APIToken)
-    resp, err := c.HTTPClient.Do(req)
+    return c.GetProjectsByTagContext(context.Background(), tag)
+}
+
+// GetProjectsByTagContext is like GetProjectsByTag but uses ctx for cancellation.
+func (c *Client) GetProjectsByTagContext(ctx context.Context, tag string) ([]Project, error) {
+    var all []Project
+    err := c.WalkProjectsByTagContext(ctx, tag, func(page []Project) error {
+        all = append(all, page...)
+        return nil
+    })
     if err != nil {
         return nil, err
     }
-    defer resp.Body.Close()
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("failed to get projects by tag: %s", resp.Status)
-    }
-    var projects []Project
-    if err := json.NewDecoder(resp.Body).Decode(&projects); err != nil {
-        return nil, err
-    }
-    return projects, nil
+    return all, nil
+}
+
+// WalkProjectsByTag calls fn with each page of projects carrying tag.
+func (c *Client) WalkProjectsByTag(tag string, fn func([]Project) error) error {
+    return c.WalkProjectsByTagContext(context.Background(), tag, fn)
+}
+
+// WalkProjectsByTagContext is like WalkProjectsByTag but uses ctx for cancellation.
+func (c *Client) WalkProjectsByTagContext(ctx context.Context, tag string, fn func([]Project) error) error {
+    // URL-encode the tag to handle special characters
+    endpoint := fmt.Sprintf("%s/api/v1/project/tag/%s", c.BaseURL, url.PathEscape(tag))
+    return c.list(ctx, "get projects by tag", endpoint, func(body io.Reader) (int, error) {
+        var page []Project
+        if err := json.NewDecoder(body).Decode(&page); err != nil {
+            return 0, err
+        }
+        if len(page) == 0 {
+            return 0, nil
+        }
+        return len(page), fn(page)
+    })
 }
 
 // GetComponentsByProjectUUID fetches components for a given project UUID.
 func (c *Client) GetComponentsByProjectUUID(projectUUID string) ([]Component, error) {
-    endpoint := fmt.Sprintf("%s/api/v1/component/project/%s", c.BaseURL, url.PathEscape(projectUUID))
-    req, err := http.NewRequest("GET", endpoint, nil)
-    if err != nil {
-        return nil, err
-    }
-    req.Header.Set("X-Api-Key", c.APIToken)
-    resp, err := c.HTTPClient.Do(req)
+    return c.GetComponentsByProjectUUIDContext(context.Background(), projectUUID)
+}
+
+// GetComponentsByProjectUUIDContext is like GetComponentsByProjectUUID but uses ctx for cancellation.
+func (c *Client) GetComponentsByProjectUUIDContext(ctx context.Context, projectUUID string) ([]Component, error) {
+    var all []Component
+    err := c.WalkComponentsByProjectUUIDContext(ctx, projectUUID, func(page []Component) error {
+        all = append(all, page...)
+        return nil
+    })
     if err != nil {
         return nil, err
     }
-    defer resp.Body.Close()
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("failed to get components: %s", resp.Status)
-    }
-    var components []Component
-    if err := json.NewDecoder(resp.Body).Decode(&components); err != nil {
-        return nil, err
-    }
-    return components, nil
+    return all, nil
+}
+
+// WalkComponentsByProjectUUID calls fn with each page of components of a project.
+func (c *Client) WalkComponentsByProjectUUID(projectUUID string, fn func([]Component) error) error {
+    return c.WalkComponentsByProjectUUIDContext(context.Background(), projectUUID, fn)
+}
+
+// WalkComponentsByProjectUUIDContext is like WalkComponentsByProjectUUID but uses ctx for cancellation.
+func (c *Client) WalkComponentsByProjectUUIDContext(ctx context.Context, projectUUID string, fn func([]Component) error) error {
+    // The repository metadata carries the publish date AGE conditions need.
+    endpoint := fmt.Sprintf("%s/api/v1/component/project/%s?includeRepositoryMetaData=true", c.BaseURL, url.PathEscape(projectUUID))
+    return c.list(ctx, "get components", endpoint, func(body io.Reader) (int, error) {
+        var page []Component
+        if err := json.NewDecoder(body).Decode(&page); err != nil {
+            return 0, err
+        }
+        if len(page) == 0 {
+            return 0, nil
+        }
+        return len(page), fn(page)
+    })
 }
 
 // GetComponentByUUID fetches a single component by its UUID.
 func (c *Client) GetComponentByUUID(componentUUID string) (*Component, error) {
+    return c.GetComponentByUUIDContext(context.Background(), componentUUID)
+}
+
+// GetComponentByUUIDContext is like GetComponentByUUID but uses ctx for cancellation.
+func (c *Client) GetComponentByUUIDContext(ctx context.Context, componentUUID string) (*Component, error) {
     endpoint := fmt.Sprintf("%s/api/v1/component/%s", c.BaseURL, url.PathEscape(componentUUID))
-    req, err := http.NewRequest("GET", endpoint, nil)
+    req, err := c.newRequest(ctx, "GET", endpoint, nil)
     if err != nil {
         return nil, err
     }
-    req.Header.Set("X-Api-Key", c.APIToken)
-    resp, err := c.HTTPClient.Do(req)
+    resp, err := c.do(req)
     if err != nil {
         return nil, err
     }
     defer resp.Body.Close()
     if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("failed to get component: %s", resp.Status)
+        return nil, newAPIError("get component", resp)
     }
     var component Component
     if err := json.NewDecoder(resp.Body).Decode(&component); err != nil {
@@ -160,10 +455,15 @@ func (c *Client) GetComponentByUUID(componentUUID string) (*Component, error) {
 // Based on the working example you found, we only need uuid, name, and sha256 in the payload.
 // We do a GET first to get the current name and ensure it's exactly the same name (including spaces).
 func (c *Client) UpdateComponentSHA256(componentUUID, newSHA256 string) error {
+    return c.UpdateComponentSHA256Context(context.Background(), componentUUID, newSHA256)
+}
+
+// UpdateComponentSHA256Context is like UpdateComponentSHA256 but uses ctx for cancellation.
+func (c *Client) UpdateComponentSHA256Context(ctx context.Context, componentUUID, newSHA256 string) error {
     // Fetch existing component details
-    existingComponent, err := c.GetComponentByUUID(componentUUID)
+    existingComponent, err := c.GetComponentByUUIDContext(ctx, componentUUID)
     if err != nil {
-        return fmt.Errorf("failed to retrieve existing component: %v", err)
+        return fmt.Errorf("failed to retrieve existing component: %w", err)
     }
 
     // Prepare the minimal payload based on the working Postman request:
@@ -181,31 +481,21 @@ func (c *Client) UpdateComponentSHA256(componentUUID, newSHA256 string) error {
 
     // Use POST to /v1/component
     endpoint := fmt.Sprintf("%s/api/v1/component", c.BaseURL)
-    req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonPayload))
+    req, err := c.newRequest(ctx, "POST", endpoint, bytes.NewBuffer(jsonPayload))
     if err != nil {
         return fmt.Errorf("failed to create POST request: %v", err)
     }
 
-    req.Header.Set("X-Api-Key", c.APIToken)
     req.Header.Set("Content-Type", "application/json")
 
-    resp, err := c.HTTPClient.Do(req)
+    resp, err := c.do(req)
     if err != nil {
-        return fmt.Errorf("failed to perform POST request: %v", err)
+        return fmt.Errorf("failed to perform POST request: %w", err)
     }
     defer resp.Body.Close()
 
     if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-        // Attempt to read the response body for more detailed error information
-        var errorResponse map[string]interface{}
-        if decodeErr := json.NewDecoder(resp.Body).Decode(&errorResponse); decodeErr != nil {
-            return fmt.Errorf("failed to update component: %s", resp.Status)
-        }
-        // If the API returns a 'message' field, include it for more context
-        if msg, exists := errorResponse["message"]; exists {
-            return fmt.Errorf("failed to update component: %s, message: %v", resp.Status, msg)
-        }
-        return fmt.Errorf("failed to update component: %s, response: %v", resp.Status, errorResponse)
+        return newAPIError("update component", resp)
     }
 
     return nil
@@ -213,90 +503,113 @@ func (c *Client) UpdateComponentSHA256(componentUUID, newSHA256 string) error {
 
 // GetPolicies fetches all policies from the Dependency-Track server.
 func (c *Client) GetPolicies() ([]Policy, error) {
-    endpoint := fmt.Sprintf("%s/api/v1/policy", c.BaseURL)
-    req, err := http.NewRequest("GET", endpoint, nil)
-    if err != nil {
-        return nil, err
-    }
-    req.Header.Set("X-Api-Key", c.APIToken)
-    resp, err := c.HTTPClient.Do(req)
+    return c.GetPoliciesContext(context.Background())
+}
+
+// GetPoliciesContext is like GetPolicies but uses ctx for cancellation.
+func (c *Client) GetPoliciesContext(ctx context.Context) ([]Policy, error) {
+    var all []Policy
+    err := c.WalkPoliciesContext(ctx, func(page []Policy) error {
+        all = append(all, page...)
+        return nil
+    })
     if err != nil {
         return nil, err
     }
-    defer resp.Body.Close()
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("failed to get policies: %s", resp.Status)
-    }
-    var policies []Policy
-    if err := json.NewDecoder(resp.Body).Decode(&policies); err != nil {
-        return nil, err
-    }
-    return policies, nil
+    return all, nil
+}
+
+// WalkPolicies calls fn with each page of policies as it is fetched.
+func (c *Client) WalkPolicies(fn func([]Policy) error) error {
+    return c.WalkPoliciesContext(context.Background(), fn)
+}
+
+// WalkPoliciesContext is like WalkPolicies but uses ctx for cancellation.
+func (c *Client) WalkPoliciesContext(ctx context.Context, fn func([]Policy) error) error {
+    endpoint := fmt.Sprintf("%s/api/v1/policy", c.BaseURL)
+    return c.list(ctx, "get policies", endpoint, func(body io.Reader) (int, error) {
+        var page []Policy
+        if err := json.NewDecoder(body).Decode(&page); err != nil {
+            return 0, err
+        }
+        if len(page) == 0 {
+            return 0, nil
+        }
+        return len(page), fn(page)
+    })
 }
 
 // UpdatePolicyCondition updates a policy's condition by sending a POST request.
 func (c *Client) UpdatePolicyCondition(condition PolicyCondition) error {
-    jsonPayload, err := json.Marshal(condition)
+    return c.UpdatePolicyConditionContext(context.Background(), condition)
+}
+
+// UpdatePolicyConditionContext is like UpdatePolicyCondition but uses ctx for cancellation.
+func (c *Client) UpdatePolicyConditionContext(ctx context.Context, condition PolicyCondition) error {
+    // Send only the fields the server accepts; Hash is a client-side view
+    // of Value.
+    payload := map[string]string{
+        "uuid":     condition.UUID,
+        "operator": condition.Operator,
+        "subject":  condition.Subject,
+        "value":    condition.Value,
+    }
+    if condition.ViolationType != "" {
+        payload["violationType"] = condition.ViolationType
+    }
+    jsonPayload, err := json.Marshal(payload)
     if err != nil {
         return fmt.Errorf("failed to marshal policy condition: %v", err)
     }
 
     endpoint := fmt.Sprintf("%s/api/v1/policy/condition", c.BaseURL)
-    req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonPayload))
+    req, err := c.newRequest(ctx, "POST", endpoint, bytes.NewBuffer(jsonPayload))
     if err != nil {
         return fmt.Errorf("failed to create POST request: %v", err)
     }
 
-    req.Header.Set("X-Api-Key", c.APIToken)
     req.Header.Set("Content-Type", "application/json")
 
-    resp, err := c.HTTPClient.Do(req)
+    resp, err := c.do(req)
     if err != nil {
-        return fmt.Errorf("failed to perform POST request: %v", err)
+        return fmt.Errorf("failed to perform POST request: %w", err)
     }
     defer resp.Body.Close()
 
     if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-        var errorResponse map[string]interface{}
-        if decodeErr := json.NewDecoder(resp.Body).Decode(&errorResponse); decodeErr != nil {
-            return fmt.Errorf("failed to update policy condition: %s", resp.Status)
-        }
-        if msg, exists := errorResponse["message"]; exists {
-            return fmt.Errorf("failed to update policy condition: %s, message: %v", resp.Status, msg)
-        }
-        return fmt.Errorf("failed to update policy condition: %s, response: %v", resp.Status, errorResponse)
+        return newAPIError("update policy condition", resp)
     }
 
     return nil
 }
 
 // GetPolicyByUUID fetches a single policy by its UUID.
-func (c *Client) GetPolicyByUUID(policyUUID string) (map[string]interface{}, error) {
+func (c *Client) GetPolicyByUUID(policyUUID string) (*Policy, error) {
+    return c.GetPolicyByUUIDContext(context.Background(), policyUUID)
+}
+
+// GetPolicyByUUIDContext is like GetPolicyByUUID but uses ctx for cancellation.
+func (c *Client) GetPolicyByUUIDContext(ctx context.Context, policyUUID string) (*Policy, error) {
     endpoint := fmt.Sprintf("%s/api/v1/policy/%s", c.BaseURL, url.PathEscape(policyUUID))
-    req, err := http.NewRequest("GET", endpoint, nil)
+    req, err := c.newRequest(ctx, "GET", endpoint, nil)
     if err != nil {
         return nil, fmt.Errorf("failed to create GET request: %v", err)
     }
 
-    req.Header.Set("X-Api-Key", c.APIToken)
-    resp, err := c.HTTPClient.Do(req)
+    resp, err := c.do(req)
     if err != nil {
-        return nil, fmt.Errorf("failed to perform GET request: %v", err)
+        return nil, fmt.Errorf("failed to perform GET request: %w", err)
     }
     defer resp.Body.Close()
 
     if resp.StatusCode != http.StatusOK {
-        var errorResponse map[string]interface{}
-        if decodeErr := json.NewDecoder(resp.Body).Decode(&errorResponse); decodeErr != nil {
-            return nil, fmt.Errorf("failed to get policy: %s", resp.Status)
-        }
-        return nil, fmt.Errorf("failed to get policy: %s, response: %v", resp.Status, errorResponse)
+        return nil, newAPIError("get policy", resp)
     }
 
-    var policy map[string]interface{}
+    var policy Policy
     if err := json.NewDecoder(resp.Body).Decode(&policy); err != nil {
         return nil, fmt.Errorf("failed to decode policy response: %v", err)
     }
 
-    return policy, nil
+    return &policy, nil
 }
@@ -0,0 +1,167 @@
+package dependencytrack_test
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "dtctl/pkg/dependencytrack"
+    "dtctl/pkg/dependencytrack/fake"
+)
+
+// ignoringPagingServer answers every project list request with the same
+// projects and no X-Total-Count, like servers that do not support paging.
+func ignoringPagingServer(t *testing.T, n int) (*dependencytrack.Client, *int) {
+    t.Helper()
+    var projects []dependencytrack.Project
+    for i := 0; i < n; i++ {
+        projects = append(projects, dependencytrack.Project{Name: fmt.Sprint("p", i), UUID: fmt.Sprint(i)})
+    }
+    requests := 0
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        requests++
+        if requests > 10 {
+            t.Errorf("page %s requested", r.URL.Query().Get("pageNumber"))
+            w.Write([]byte("[]"))
+            return
+        }
+        json.NewEncoder(w).Encode(projects)
+    }))
+    t.Cleanup(srv.Close)
+    client := dependencytrack.NewClient(srv.URL, "token")
+    client.PageSize = 2
+    return client, &requests
+}
+
+func TestListStopsWhenServerIgnoresPaging(t *testing.T) {
+    for _, n := range []int{2, 3} {
+        client, requests := ignoringPagingServer(t, n)
+        projects, err := client.GetProjects()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if len(projects) != n {
+            t.Errorf("%d projects per page: got %d projects, want %d", n, len(projects), n)
+        }
+        if *requests > 2 {
+            t.Errorf("%d projects per page: %d requests", n, *requests)
+        }
+    }
+}
+
+func TestWalkStopsOnWrappedErrStopWalk(t *testing.T) {
+    client, requests := ignoringPagingServer(t, 2)
+    err := client.WalkProjects(func([]dependencytrack.Project) error {
+        return fmt.Errorf("enough: %w", dependencytrack.ErrStopWalk)
+    })
+    if err != nil {
+        t.Errorf("WalkProjects = %v, want nil", err)
+    }
+    if *requests != 1 {
+        t.Errorf("%d requests, want 1", *requests)
+    }
+}
+
+func TestRetry(t *testing.T) {
+    fast := dependencytrack.RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+    retryAfter := func(v string) http.Header { return http.Header{"Retry-After": []string{v}} }
+    tests := []struct {
+        name   string
+        policy dependencytrack.RetryPolicy
+        fault  fake.Fault
+        // update sends the POST of UpdateComponentSHA256 instead of a GET.
+        update     bool
+        wantStatus int
+        wantTries  int
+        minElapsed time.Duration
+    }{
+        {name: "transient failure", policy: fast, fault: fake.Fault{Status: http.StatusServiceUnavailable, Times: 2}, wantTries: 3},
+        {name: "retries exhausted", policy: fast, fault: fake.Fault{Status: http.StatusBadGateway}, wantStatus: http.StatusBadGateway, wantTries: 3},
+        {name: "retrying disabled", policy: dependencytrack.RetryPolicy{}, fault: fake.Fault{Status: http.StatusServiceUnavailable, Times: 1}, wantStatus: http.StatusServiceUnavailable, wantTries: 1},
+        {name: "not transient", policy: fast, fault: fake.Fault{Status: http.StatusInternalServerError}, wantStatus: http.StatusInternalServerError, wantTries: 1},
+        {name: "update", policy: fast, fault: fake.Fault{Method: "POST", Status: http.StatusServiceUnavailable, Times: 1}, update: true, wantStatus: http.StatusServiceUnavailable, wantTries: 1},
+        {name: "update with RetryUpdates", policy: dependencytrack.RetryPolicy{MaxRetries: 2, RetryUpdates: true}, fault: fake.Fault{Method: "POST", Status: http.StatusServiceUnavailable, Times: 1}, update: true, wantTries: 2},
+        {name: "Retry-After", policy: fast, fault: fake.Fault{Status: http.StatusTooManyRequests, Header: retryAfter("1"), Times: 1}, wantTries: 2, minElapsed: time.Second},
+        {name: "Retry-After beyond MaxBackoff", policy: dependencytrack.RetryPolicy{MaxRetries: 1, MaxBackoff: time.Millisecond}, fault: fake.Fault{Status: http.StatusTooManyRequests, Header: retryAfter("1"), Times: 1}, wantTries: 2, minElapsed: time.Second},
+        {name: "Retry-After beyond the timeout", policy: fast, fault: fake.Fault{Status: http.StatusTooManyRequests, Header: retryAfter("120")}, wantStatus: http.StatusTooManyRequests, wantTries: 1},
+        {name: "Retry-After ignored", policy: dependencytrack.RetryPolicy{MaxRetries: 1, IgnoreRetryAfter: true}, fault: fake.Fault{Status: http.StatusTooManyRequests, Header: retryAfter("120"), Times: 1}, wantTries: 2},
+    }
+    for _, tt := range tests {
+        srv := fake.NewServer(fake.Fixtures{Components: []dependencytrack.Component{{Name: "c", UUID: "c1"}}})
+        defer srv.Close()
+        tt.fault.Path = "/api/v1/component"
+        srv.InjectFault(tt.fault)
+        client := srv.Client()
+        client.Retry = tt.policy
+
+        start := time.Now()
+        var err error
+        if tt.update {
+            err = client.UpdateComponentSHA256("c1", "ff")
+        } else {
+            _, err = client.GetComponentByUUID("c1")
+        }
+        elapsed := time.Since(start)
+
+        var apiErr *dependencytrack.APIError
+        switch {
+        case tt.wantStatus == 0 && err != nil:
+            t.Errorf("%s: %v", tt.name, err)
+        case tt.wantStatus != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus):
+            t.Errorf("%s: got error %v, want status %d", tt.name, err, tt.wantStatus)
+        }
+        tries := 0
+        for _, r := range srv.Requests() {
+            if r.Path == "/api/v1/component" || r.Path == "/api/v1/component/c1" {
+                if (r.Method == "POST") == tt.update {
+                    tries++
+                }
+            }
+        }
+        if tries != tt.wantTries {
+            t.Errorf("%s: %d tries, want %d", tt.name, tries, tt.wantTries)
+        }
+        if elapsed < tt.minElapsed || elapsed > tt.minElapsed+5*time.Second {
+            t.Errorf("%s: took %v, want at least %v", tt.name, elapsed, tt.minElapsed)
+        }
+    }
+}
+
+func TestRetryStopsWhenContextEnds(t *testing.T) {
+    srv := fake.NewServer(fake.Fixtures{})
+    defer srv.Close()
+    srv.InjectFault(fake.Fault{Path: "/api/v1/project", Status: http.StatusServiceUnavailable})
+    srv.InjectFault(fake.Fault{Path: "/api/v1/policy", Status: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"5"}}})
+    client := srv.Client()
+    client.HTTPClient.Timeout = 0
+    client.Retry = dependencytrack.RetryPolicy{MaxRetries: 3, MinBackoff: time.Minute, MaxBackoff: time.Minute}
+
+    // Canceled while sleeping between retries.
+    ctx, cancel := context.WithCancel(context.Background())
+    time.AfterFunc(50*time.Millisecond, cancel)
+    start := time.Now()
+    if _, err := client.GetProjectsContext(ctx); !errors.Is(err, context.Canceled) {
+        t.Errorf("GetProjectsContext = %v, want context.Canceled", err)
+    }
+    if elapsed := time.Since(start); elapsed > 5*time.Second {
+        t.Errorf("canceled retry took %v", elapsed)
+    }
+
+    // A Retry-After past the deadline fails right away.
+    ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+    defer cancel()
+    start = time.Now()
+    _, err := client.GetPoliciesContext(ctx)
+    var apiErr *dependencytrack.APIError
+    if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+        t.Errorf("GetPoliciesContext = %v, want the 429", err)
+    }
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Errorf("Retry-After past the deadline waited %v", elapsed)
+    }
+}
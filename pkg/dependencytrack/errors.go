@@ -0,0 +1,99 @@
+package dependencytrack
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "strings"
+)
+
+// maxErrorBody caps how much of an error response is read for its message.
+const maxErrorBody = 64 << 10
+
+// APIError is returned by Client methods when the server answers with an
+// unexpected status code.
+type APIError struct {
+    // Op describes the failed operation, e.g. "get projects".
+    Op string
+    // Method and Endpoint identify the request, without its query string.
+    Method   string
+    Endpoint string
+    // StatusCode and Status are taken from the response.
+    StatusCode int
+    Status     string
+    // Message is the server's explanation, if the response had one.
+    Message string
+    // RequestID is the request or correlation ID reported by the server or
+    // a proxy in front of it, if any.
+    RequestID string
+}
+
+func (e *APIError) Error() string {
+    msg := fmt.Sprintf("failed to %s: %s", e.Op, e.Status)
+    if e.Message != "" {
+        msg += ", message: " + e.Message
+    }
+    if e.RequestID != "" {
+        msg += " (request id " + e.RequestID + ")"
+    }
+    return msg
+}
+
+// newAPIError builds an APIError from resp, reading its body for a message.
+// The caller still owns and must close resp.Body.
+func newAPIError(op string, resp *http.Response) *APIError {
+    e := &APIError{
+        Op:         op,
+        StatusCode: resp.StatusCode,
+        Status:     resp.Status,
+        RequestID:  requestID(resp.Header),
+    }
+    if resp.Request != nil {
+        e.Method = resp.Request.Method
+        e.Endpoint = resp.Request.URL.Path
+    }
+    body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+    e.Message = errorMessage(body)
+    return e
+}
+
+// errorMessage extracts a human-readable message from an error body.
+// Dependency-Track answers with a JSON object carrying "message", a JSON
+// string, or plain text depending on the endpoint.
+func errorMessage(body []byte) string {
+    text := strings.TrimSpace(string(body))
+    if text == "" {
+        return ""
+    }
+
+    var obj map[string]interface{}
+    if err := json.Unmarshal(body, &obj); err == nil {
+        for _, key := range []string{"message", "error", "detail", "title"} {
+            if msg, ok := obj[key].(string); ok && msg != "" {
+                return msg
+            }
+        }
+        return text
+    }
+    var str string
+    if err := json.Unmarshal(body, &str); err == nil {
+        return str
+    }
+    // Skip HTML error pages from proxies; the status says enough.
+    if strings.HasPrefix(text, "<") {
+        return ""
+    }
+    return text
+}
+
+// requestID returns the first request correlation header present in h.
+func requestID(h http.Header) string {
+    for _, key := range []string{"X-Request-Id", "X-Correlation-Id", "X-Amzn-Trace-Id"} {
+        if v := h.Get(key); v != "" {
+            return v
+        }
+    }
+    return ""
+}
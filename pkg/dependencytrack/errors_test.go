@@ -0,0 +1,76 @@
+package dependencytrack
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func TestErrorMessage(t *testing.T) {
+    tests := []struct {
+        name string
+        body string
+        want string
+    }{
+        {"empty", "", ""},
+        {"blank", " \n", ""},
+        {"JSON message", `{"message":"Project not found","status":404}`, "Project not found"},
+        {"JSON error", `{"error":"invalid token"}`, "invalid token"},
+        {"JSON detail", `{"title":"Conflict","detail":"name already exists"}`, "name already exists"},
+        {"JSON title", `{"title":"Forbidden","message":""}`, "Forbidden"},
+        {"JSON without message", `{"code":42}`, `{"code":42}`},
+        {"JSON string", `"The UUID of the component could not be found."`, "The UUID of the component could not be found."},
+        {"plain text", "Unauthorized\n", "Unauthorized"},
+        {"HTML", "<html><body><h1>502 Bad Gateway</h1></body></html>", ""},
+        {"HTML with doctype", "\n<!DOCTYPE html><html></html>", ""},
+    }
+    for _, tt := range tests {
+        if got := errorMessage([]byte(tt.body)); got != tt.want {
+            t.Errorf("%s: errorMessage(%q) = %q, want %q", tt.name, tt.body, got, tt.want)
+        }
+    }
+}
+
+func TestRequestID(t *testing.T) {
+    tests := []struct {
+        header http.Header
+        want   string
+    }{
+        {http.Header{}, ""},
+        {http.Header{"X-Request-Id": {"req-1"}}, "req-1"},
+        {http.Header{"X-Correlation-Id": {"corr-1"}}, "corr-1"},
+        {http.Header{"X-Amzn-Trace-Id": {"Root=1-abc"}}, "Root=1-abc"},
+        {http.Header{"X-Amzn-Trace-Id": {"Root=1-abc"}, "X-Request-Id": {"req-1"}}, "req-1"},
+    }
+    for _, tt := range tests {
+        if got := requestID(tt.header); got != tt.want {
+            t.Errorf("requestID(%v) = %q, want %q", tt.header, got, tt.want)
+        }
+    }
+}
+
+func TestNewAPIError(t *testing.T) {
+    rec := httptest.NewRecorder()
+    rec.Header().Set("X-Request-Id", "req-1")
+    rec.WriteHeader(http.StatusNotFound)
+    rec.WriteString(`{"message":"Project not found"}`)
+    resp := rec.Result()
+    resp.Request = httptest.NewRequest("GET", "https://dt.example.com/api/v1/project/p1?pageNumber=1", nil)
+
+    e := newAPIError("get project", resp)
+    if e.StatusCode != http.StatusNotFound || e.Method != "GET" || e.Endpoint != "/api/v1/project/p1" || e.Message != "Project not found" || e.RequestID != "req-1" {
+        t.Errorf("newAPIError = %+v", e)
+    }
+    want := "failed to get project: 404 Not Found, message: Project not found (request id req-1)"
+    if e.Error() != want {
+        t.Errorf("Error() = %q, want %q", e.Error(), want)
+    }
+
+    rec = httptest.NewRecorder()
+    rec.WriteHeader(http.StatusBadGateway)
+    rec.WriteString("<html>" + strings.Repeat("x", 2*maxErrorBody) + "</html>")
+    if e := newAPIError("get projects", rec.Result()); e.Error() != "failed to get projects: 502 Bad Gateway" {
+        t.Errorf("Error() = %q", e.Error())
+    }
+}
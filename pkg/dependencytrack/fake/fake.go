@@ -0,0 +1,658 @@
+// Package fake provides an in-memory Dependency-Track server for tests.
+//
+// A Server is a stateful httptest.Server implementing the version, team,
+// project, component and policy endpoints used by dependencytrack.Client.
+// Tests seed it with fixtures, point a client at it, and inspect the requests
+// it received afterwards. Faults and latency can be injected to exercise
+// error handling.
+package fake
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "dtctl/pkg/dependencytrack"
+)
+
+// Token is the API key the server accepts unless Server.Token is changed.
+const Token = "fake-api-key"
+
+// Version is the version the server reports unless the fixtures set one.
+const Version = "4.11.0"
+
+// DefaultTeam returns the team the API key belongs to unless the fixtures
+// set one. It has every permission dtctl uses.
+func DefaultTeam() *dependencytrack.Team {
+    return &dependencytrack.Team{
+        UUID: "7d2c4a39-0f6e-4c39-9d0b-5b2a1f0c8e11",
+        Name: "Automation",
+        Permissions: []dependencytrack.Permission{
+            {Name: dependencytrack.PermissionPolicyManagement},
+            {Name: dependencytrack.PermissionPortfolioManagement},
+            {Name: dependencytrack.PermissionViewPortfolio},
+            {Name: dependencytrack.PermissionViewVulnerability},
+        },
+    }
+}
+
+// Fixtures is the state a Server can be seeded with. Components are attached
+// to the project named by their Project.UUID.
+type Fixtures struct {
+    Projects   []dependencytrack.Project   `json:"projects"`
+    Components []dependencytrack.Component `json:"components"`
+    Policies   []dependencytrack.Policy    `json:"policies"`
+    // LicenseGroups are served by UUID, for LICENSE_GROUP conditions.
+    LicenseGroups []dependencytrack.LicenseGroup `json:"licenseGroups,omitempty"`
+    // Findings attach vulnerabilities to components by the component's UUID
+    // and project.
+    Findings []dependencytrack.Finding `json:"findings,omitempty"`
+    // Version and Team default to the Version constant and DefaultTeam.
+    Version string                `json:"version,omitempty"`
+    Team    *dependencytrack.Team `json:"team,omitempty"`
+}
+
+// LoadFixtures decodes fixtures from a JSON document.
+func LoadFixtures(r io.Reader) (Fixtures, error) {
+    var f Fixtures
+    if err := json.NewDecoder(r).Decode(&f); err != nil {
+        return f, fmt.Errorf("failed to decode fixtures: %v", err)
+    }
+    return f, nil
+}
+
+// Fault makes the server answer matching requests with an error instead of
+// serving them.
+type Fault struct {
+    // Method and Path select the requests the fault applies to. An empty
+    // Method matches every method; Path matches by prefix and includes the
+    // /api/v1 prefix, e.g. "/api/v1/component/project/".
+    Method string
+    Path   string
+    // Status and Body are written in place of the real response. A zero
+    // Status only delays the request by Latency.
+    Status int
+    Body   string
+    // Header is added to the response, e.g. Retry-After.
+    Header http.Header
+    // Latency delays the response.
+    Latency time.Duration
+    // Times limits how many requests the fault applies to; 0 means every
+    // request.
+    Times int
+}
+
+// Request is a request received by the server.
+type Request struct {
+    Method string
+    Path   string
+    Query  url.Values
+    Header http.Header
+    Body   []byte
+}
+
+// Server is an in-memory Dependency-Track server.
+type Server struct {
+    *httptest.Server
+
+    // Token is the API key requests must carry in X-Api-Key. An empty
+    // Token accepts every request.
+    Token string
+
+    mu            sync.Mutex
+    projects      []dependencytrack.Project
+    components    []dependencytrack.Component
+    policies      []dependencytrack.Policy
+    licenseGroups []dependencytrack.LicenseGroup
+    findings      []dependencytrack.Finding
+    version       string
+    team          *dependencytrack.Team
+    faults        []*Fault
+    latency       time.Duration
+    requests      []Request
+}
+
+// NewServer starts a Server seeded with fixtures. The caller must Close it.
+func NewServer(fixtures Fixtures) *Server {
+    s := &Server{Token: Token}
+    s.Seed(fixtures)
+    s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+    return s
+}
+
+// NewTLSServer is like NewServer but serves HTTPS with a self-signed
+// certificate, which the server's Client trusts.
+func NewTLSServer(fixtures Fixtures) *Server {
+    s := &Server{Token: Token}
+    s.Seed(fixtures)
+    s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+    return s
+}
+
+// Client returns a client for the server, authenticated with its token and
+// without retries.
+func (s *Server) Client() *dependencytrack.Client {
+    client := dependencytrack.NewClient(s.URL, s.Token)
+    client.Retry.MaxRetries = 0
+    if s.TLS != nil {
+        client.HTTPClient.Transport = s.Server.Client().Transport
+    }
+    return client
+}
+
+// Seed replaces the server's state with fixtures.
+func (s *Server) Seed(fixtures Fixtures) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.projects = append([]dependencytrack.Project(nil), fixtures.Projects...)
+    s.components = append([]dependencytrack.Component(nil), fixtures.Components...)
+    s.policies = make([]dependencytrack.Policy, len(fixtures.Policies))
+    for i, p := range fixtures.Policies {
+        p.PolicyConditions = append([]dependencytrack.PolicyCondition(nil), p.PolicyConditions...)
+        s.policies[i] = p
+    }
+    s.licenseGroups = append([]dependencytrack.LicenseGroup(nil), fixtures.LicenseGroups...)
+    s.findings = append([]dependencytrack.Finding(nil), fixtures.Findings...)
+    s.version = fixtures.Version
+    if s.version == "" {
+        s.version = Version
+    }
+    s.team = fixtures.Team
+    if s.team == nil {
+        s.team = DefaultTeam()
+    }
+}
+
+// Fixtures returns the server's current state, including updates made
+// through the API.
+func (s *Server) Fixtures() Fixtures {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    f := Fixtures{
+        Projects:      append([]dependencytrack.Project(nil), s.projects...),
+        Components:    append([]dependencytrack.Component(nil), s.components...),
+        Policies:      make([]dependencytrack.Policy, len(s.policies)),
+        LicenseGroups: append([]dependencytrack.LicenseGroup(nil), s.licenseGroups...),
+        Findings:      append([]dependencytrack.Finding(nil), s.findings...),
+        Version:       s.version,
+        Team:          s.team,
+    }
+    for i, p := range s.policies {
+        p.PolicyConditions = append([]dependencytrack.PolicyCondition(nil), p.PolicyConditions...)
+        f.Policies[i] = p
+    }
+    return f
+}
+
+// AddProject adds a project to the server.
+func (s *Server) AddProject(p dependencytrack.Project) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.projects = append(s.projects, p)
+}
+
+// AddComponent adds a component to the project with the given UUID.
+func (s *Server) AddComponent(projectUUID string, c dependencytrack.Component) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    c.Project.UUID = projectUUID
+    s.components = append(s.components, c)
+}
+
+// AddLicenseGroup adds a license group to the server.
+func (s *Server) AddLicenseGroup(g dependencytrack.LicenseGroup) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.licenseGroups = append(s.licenseGroups, g)
+}
+
+// AddFinding adds a finding to the server.
+func (s *Server) AddFinding(f dependencytrack.Finding) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.findings = append(s.findings, f)
+}
+
+// AddPolicy adds a policy to the server.
+func (s *Server) AddPolicy(p dependencytrack.Policy) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.policies = append(s.policies, p)
+}
+
+// SetTeam replaces the team the API key belongs to. nil makes the server
+// answer 404 for it, like servers older than Dependency-Track 4.11.
+func (s *Server) SetTeam(t *dependencytrack.Team) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.team = t
+}
+
+// InjectFault registers a fault. Faults are tried in the order they were
+// injected; the first one matching a request applies.
+func (s *Server) InjectFault(f Fault) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every injected fault and the global latency.
+func (s *Server) ClearFaults() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.faults = nil
+    s.latency = 0
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.latency = d
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([]Request(nil), s.requests...)
+}
+
+// ResetRequests forgets the recorded requests.
+func (s *Server) ResetRequests() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.requests = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+    body, _ := ioutil.ReadAll(r.Body)
+    r.Body.Close()
+
+    s.mu.Lock()
+    s.requests = append(s.requests, Request{
+        Method: r.Method,
+        Path:   r.URL.Path,
+        Query:  r.URL.Query(),
+        Header: r.Header.Clone(),
+        Body:   body,
+    })
+    latency := s.latency
+    fault := s.matchFault(r)
+    s.mu.Unlock()
+
+    if fault != nil {
+        latency += fault.Latency
+    }
+    if latency > 0 {
+        select {
+        case <-time.After(latency):
+        case <-r.Context().Done():
+            return
+        }
+    }
+    if fault != nil && fault.Status != 0 {
+        for k, v := range fault.Header {
+            w.Header()[k] = v
+        }
+        w.WriteHeader(fault.Status)
+        io.WriteString(w, fault.Body)
+        return
+    }
+
+    // The version endpoint is public, like on a real server.
+    if r.Method == "GET" && r.URL.Path == "/api/version" {
+        s.mu.Lock()
+        version := s.version
+        s.mu.Unlock()
+        writeJSON(w, http.StatusOK, dependencytrack.About{Application: "Dependency-Track", Version: version})
+        return
+    }
+    if s.Token != "" && r.Header.Get("X-Api-Key") != s.Token {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    s.route(w, r, body)
+}
+
+// matchFault returns the first fault applying to r and uses it up. The caller
+// must hold s.mu.
+func (s *Server) matchFault(r *http.Request) *Fault {
+    for i, f := range s.faults {
+        if f.Method != "" && f.Method != r.Method {
+            continue
+        }
+        if !strings.HasPrefix(r.URL.Path, f.Path) {
+            continue
+        }
+        if f.Times > 0 {
+            f.Times--
+            if f.Times == 0 {
+                s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+            }
+        }
+        return f
+    }
+    return nil
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, body []byte) {
+    path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+    switch {
+    case r.Method == "GET" && path == "/team/self":
+        s.getTeam(w, r)
+    case r.Method == "GET" && path == "/project":
+        s.listProjects(w, r, "")
+    case r.Method == "GET" && strings.HasPrefix(path, "/project/tag/"):
+        s.listProjects(w, r, strings.TrimPrefix(path, "/project/tag/"))
+    case r.Method == "GET" && strings.HasPrefix(path, "/component/project/"):
+        s.listComponents(w, r, strings.TrimPrefix(path, "/component/project/"))
+    case r.Method == "GET" && strings.HasPrefix(path, "/component/"):
+        s.getComponent(w, strings.TrimPrefix(path, "/component/"))
+    case r.Method == "POST" && path == "/component":
+        s.updateComponent(w, body)
+    case r.Method == "GET" && path == "/policy":
+        s.listPolicies(w, r)
+    case r.Method == "POST" && path == "/policy/condition":
+        s.updateCondition(w, body)
+    case r.Method == "GET" && strings.HasPrefix(path, "/policy/"):
+        s.getPolicy(w, strings.TrimPrefix(path, "/policy/"))
+    case r.Method == "GET" && strings.HasPrefix(path, "/finding/project/"):
+        s.listFindings(w, r, strings.TrimPrefix(path, "/finding/project/"))
+    case r.Method == "GET" && strings.HasPrefix(path, "/vulnerability/project/"):
+        s.listVulnerabilities(w, r, strings.TrimPrefix(path, "/vulnerability/project/"), "")
+    case r.Method == "GET" && strings.HasPrefix(path, "/vulnerability/component/"):
+        s.listVulnerabilities(w, r, "", strings.TrimPrefix(path, "/vulnerability/component/"))
+    case r.Method == "GET" && strings.HasPrefix(path, "/licenseGroup/"):
+        s.getLicenseGroup(w, strings.TrimPrefix(path, "/licenseGroup/"))
+    default:
+        http.NotFound(w, r)
+    }
+}
+
+func (s *Server) getTeam(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.team == nil {
+        http.NotFound(w, r)
+        return
+    }
+    writeJSON(w, http.StatusOK, s.team)
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request, tag string) {
+    s.mu.Lock()
+    var projects []dependencytrack.Project
+    for _, p := range s.projects {
+        if tag == "" || hasTag(p.Tags, tag) {
+            projects = append(projects, p)
+        }
+    }
+    s.mu.Unlock()
+
+    start, end := page(r, len(projects))
+    writeList(w, len(projects), projects[start:end])
+}
+
+func (s *Server) listComponents(w http.ResponseWriter, r *http.Request, projectUUID string) {
+    s.mu.Lock()
+    if s.project(projectUUID) == nil {
+        s.mu.Unlock()
+        http.Error(w, "The project could not be found.", http.StatusNotFound)
+        return
+    }
+    var components []dependencytrack.Component
+    for _, c := range s.components {
+        if c.Project.UUID == projectUUID {
+            components = append(components, c)
+        }
+    }
+    s.mu.Unlock()
+
+    start, end := page(r, len(components))
+    writeList(w, len(components), components[start:end])
+}
+
+func (s *Server) getComponent(w http.ResponseWriter, uuid string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    c := s.component(uuid)
+    if c == nil {
+        http.Error(w, "The component could not be found.", http.StatusNotFound)
+        return
+    }
+    writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) updateComponent(w http.ResponseWriter, body []byte) {
+    var update struct {
+        UUID   string  `json:"uuid"`
+        Name   string  `json:"name"`
+        Sha256 *string `json:"sha256"`
+        Sha1   *string `json:"sha1"`
+        Md5    *string `json:"md5"`
+    }
+    if err := json.Unmarshal(body, &update); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    c := s.component(update.UUID)
+    if c == nil {
+        http.Error(w, "The UUID of the component could not be found.", http.StatusNotFound)
+        return
+    }
+    if update.Name != "" {
+        c.Name = update.Name
+    }
+    if update.Sha256 != nil {
+        c.Sha256 = *update.Sha256
+    }
+    if update.Sha1 != nil {
+        c.Sha1 = *update.Sha1
+    }
+    if update.Md5 != nil {
+        c.Md5 = *update.Md5
+    }
+    writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) listPolicies(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    policies := append([]dependencytrack.Policy(nil), s.policies...)
+    s.mu.Unlock()
+
+    start, end := page(r, len(policies))
+    writeList(w, len(policies), policies[start:end])
+}
+
+func (s *Server) getPolicy(w http.ResponseWriter, uuid string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    for _, p := range s.policies {
+        if p.UUID == uuid {
+            writeJSON(w, http.StatusOK, p)
+            return
+        }
+    }
+    http.Error(w, "The policy could not be found.", http.StatusNotFound)
+}
+
+// projectFindings returns the findings of a project, or of a component when
+// componentUUID is set, leaving out suppressed ones unless the request asks
+// for them. It answers 404 and returns false for unknown projects and
+// components.
+func (s *Server) projectFindings(w http.ResponseWriter, r *http.Request, projectUUID, componentUUID string) ([]dependencytrack.Finding, bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if componentUUID != "" && s.component(componentUUID) == nil {
+        http.Error(w, "The component could not be found.", http.StatusNotFound)
+        return nil, false
+    }
+    if projectUUID != "" && s.project(projectUUID) == nil {
+        http.Error(w, "The project could not be found.", http.StatusNotFound)
+        return nil, false
+    }
+    suppressed := r.URL.Query().Get("suppressed") == "true"
+    var findings []dependencytrack.Finding
+    for _, f := range s.findings {
+        if projectUUID != "" && f.Component.Project != projectUUID {
+            continue
+        }
+        if componentUUID != "" && f.Component.UUID != componentUUID {
+            continue
+        }
+        if f.Analysis.IsSuppressed && !suppressed {
+            continue
+        }
+        findings = append(findings, f)
+    }
+    return findings, true
+}
+
+func (s *Server) listFindings(w http.ResponseWriter, r *http.Request, projectUUID string) {
+    findings, ok := s.projectFindings(w, r, projectUUID, "")
+    if !ok {
+        return
+    }
+    // Like the real server, findings are not paged.
+    writeList(w, len(findings), findings)
+}
+
+func (s *Server) listVulnerabilities(w http.ResponseWriter, r *http.Request, projectUUID, componentUUID string) {
+    findings, ok := s.projectFindings(w, r, projectUUID, componentUUID)
+    if !ok {
+        return
+    }
+    var vulns []dependencytrack.Vulnerability
+    seen := map[string]bool{}
+    for _, f := range findings {
+        if !seen[f.Vulnerability.UUID] {
+            seen[f.Vulnerability.UUID] = true
+            vulns = append(vulns, f.Vulnerability)
+        }
+    }
+    start, end := page(r, len(vulns))
+    writeList(w, len(vulns), vulns[start:end])
+}
+
+func (s *Server) getLicenseGroup(w http.ResponseWriter, uuid string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    for _, g := range s.licenseGroups {
+        if g.UUID == uuid {
+            writeJSON(w, http.StatusOK, g)
+            return
+        }
+    }
+    http.Error(w, "The license group could not be found.", http.StatusNotFound)
+}
+
+func (s *Server) updateCondition(w http.ResponseWriter, body []byte) {
+    var update dependencytrack.PolicyCondition
+    if err := json.Unmarshal(body, &update); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    for i := range s.policies {
+        for j := range s.policies[i].PolicyConditions {
+            pc := &s.policies[i].PolicyConditions[j]
+            if pc.UUID != update.UUID {
+                continue
+            }
+            pc.Operator = update.Operator
+            pc.Subject = update.Subject
+            pc.Value = update.Value
+            pc.Hash = update.Hash
+            if update.ViolationType != "" {
+                pc.ViolationType = update.ViolationType
+            }
+            writeJSON(w, http.StatusOK, pc)
+            return
+        }
+    }
+    http.Error(w, "The UUID of the policy condition could not be found.", http.StatusNotFound)
+}
+
+// project returns the project with the given UUID. The caller must hold s.mu.
+func (s *Server) project(uuid string) *dependencytrack.Project {
+    for i := range s.projects {
+        if s.projects[i].UUID == uuid {
+            return &s.projects[i]
+        }
+    }
+    return nil
+}
+
+// component returns the component with the given UUID. The caller must hold
+// s.mu.
+func (s *Server) component(uuid string) *dependencytrack.Component {
+    for i := range s.components {
+        if s.components[i].UUID == uuid {
+            return &s.components[i]
+        }
+    }
+    return nil
+}
+
+func hasTag(tags []dependencytrack.Tag, name string) bool {
+    for _, t := range tags {
+        if t.Name == name {
+            return true
+        }
+    }
+    return false
+}
+
+// page returns the bounds of the page requested by r's pageNumber and
+// pageSize parameters. Without them the whole list is returned, like the
+// real server does.
+func page(r *http.Request, total int) (int, int) {
+    query := r.URL.Query()
+    number, err1 := strconv.Atoi(query.Get("pageNumber"))
+    size, err2 := strconv.Atoi(query.Get("pageSize"))
+    if err1 != nil || err2 != nil || number < 1 || size < 1 {
+        return 0, total
+    }
+    start := (number - 1) * size
+    if start > total {
+        start = total
+    }
+    end := start + size
+    if end > total {
+        end = total
+    }
+    return start, end
+}
+
+// writeList writes a page of a list with the X-Total-Count header the client
+// pages by. A nil page is written as an empty array.
+func writeList(w http.ResponseWriter, total int, items interface{}) {
+    w.Header().Set("X-Total-Count", strconv.Itoa(total))
+    data, _ := json.Marshal(items)
+    if string(data) == "null" {
+        data = []byte("[]")
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(v)
+}
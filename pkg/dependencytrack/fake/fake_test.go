@@ -0,0 +1,110 @@
+package fake
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "testing"
+    "time"
+
+    "dtctl/pkg/dependencytrack"
+)
+
+var testFixtures = Fixtures{
+    Projects: []dependencytrack.Project{
+        {Name: "one", UUID: "p1"},
+        {Name: "two", UUID: "p2"},
+    },
+}
+
+func TestFaultTimesThenRetrySucceeds(t *testing.T) {
+    srv := NewServer(testFixtures)
+    defer srv.Close()
+    srv.InjectFault(Fault{Method: "GET", Path: "/api/v1/project", Status: http.StatusServiceUnavailable, Times: 2})
+
+    client := srv.Client()
+    client.Retry.MaxRetries = 2
+    client.Retry.MinBackoff = time.Millisecond
+    client.Retry.MaxBackoff = time.Millisecond
+
+    projects, err := client.GetProjects()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(projects) != 2 {
+        t.Errorf("got %d projects, want 2", len(projects))
+    }
+    if n := len(srv.Requests()); n != 3 {
+        t.Errorf("got %d requests, want 3", n)
+    }
+}
+
+func TestLatencyHonorsContext(t *testing.T) {
+    srv := NewServer(testFixtures)
+    defer srv.Close()
+    srv.SetLatency(time.Second)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    if _, err := srv.Client().GetProjectsContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("got %v, want context.DeadlineExceeded", err)
+    }
+}
+
+func TestRejectsWrongToken(t *testing.T) {
+    srv := NewServer(testFixtures)
+    defer srv.Close()
+
+    client := dependencytrack.NewClient(srv.URL, "wrong")
+    _, err := client.GetProjects()
+    var apiErr *dependencytrack.APIError
+    if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+        t.Fatalf("got %v, want a 401 APIError", err)
+    }
+}
+
+func TestRecordsRequests(t *testing.T) {
+    srv := NewServer(testFixtures)
+    defer srv.Close()
+
+    if _, err := srv.Client().GetComponentByUUID("missing"); err == nil {
+        t.Fatal("expected an error for an unknown component")
+    }
+    reqs := srv.Requests()
+    if len(reqs) != 1 || reqs[0].Path != "/api/v1/component/missing" || reqs[0].Header.Get("X-Api-Key") != Token {
+        t.Errorf("unexpected requests: %+v", reqs)
+    }
+}
+
+func TestFindingsLeaveOutSuppressed(t *testing.T) {
+    srv := NewServer(Fixtures{
+        Projects:   []dependencytrack.Project{{Name: "one", UUID: "p1"}},
+        Components: []dependencytrack.Component{{Name: "c", UUID: "c1", Project: dependencytrack.ProjectReference{UUID: "p1"}}},
+        Findings: []dependencytrack.Finding{
+            {Component: dependencytrack.FindingComponent{UUID: "c1", Project: "p1"}, Vulnerability: dependencytrack.Vulnerability{UUID: "v1", VulnID: "CVE-1"}},
+            {Component: dependencytrack.FindingComponent{UUID: "c1", Project: "p1"}, Vulnerability: dependencytrack.Vulnerability{UUID: "v2", VulnID: "CVE-2"}, Analysis: dependencytrack.Analysis{IsSuppressed: true}},
+        },
+    })
+    defer srv.Close()
+    client := srv.Client()
+
+    findings, err := client.GetProjectFindings("p1", false)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(findings) != 1 || findings[0].Vulnerability.VulnID != "CVE-1" {
+        t.Errorf("findings without suppressed = %+v, want CVE-1 only", findings)
+    }
+    if findings, _ := client.GetProjectFindings("p1", true); len(findings) != 2 {
+        t.Errorf("got %d findings with suppressed, want 2", len(findings))
+    }
+    if vulns, err := client.GetComponentVulnerabilities("c1", false); err != nil || len(vulns) != 1 {
+        t.Errorf("component vulnerabilities = %+v, %v, want 1", vulns, err)
+    }
+    if vulns, err := client.GetProjectVulnerabilities("p1", true); err != nil || len(vulns) != 2 {
+        t.Errorf("project vulnerabilities = %+v, %v, want 2", vulns, err)
+    }
+    if _, err := client.GetComponentVulnerabilities("missing", false); err == nil {
+        t.Errorf("expected an error for an unknown component")
+    }
+}
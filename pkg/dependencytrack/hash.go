@@ -0,0 +1,81 @@
+package dependencytrack
+
+import "strings"
+
+// Hash algorithms as named in COMPONENT_HASH conditions, which follow
+// CycloneDX.
+const (
+    HashMD5        = "MD5"
+    HashSHA1       = "SHA-1"
+    HashSHA256     = "SHA-256"
+    HashSHA384     = "SHA-384"
+    HashSHA512     = "SHA-512"
+    HashSHA3256    = "SHA3-256"
+    HashSHA3384    = "SHA3-384"
+    HashSHA3512    = "SHA3-512"
+    HashBLAKE2b256 = "BLAKE2b-256"
+    HashBLAKE2b384 = "BLAKE2b-384"
+    HashBLAKE2b512 = "BLAKE2b-512"
+    HashBLAKE3     = "BLAKE3"
+)
+
+// componentHashes maps each hash algorithm to the component field holding
+// it, in the order Dependency-Track lists them.
+var componentHashes = []struct {
+    algorithm string
+    field     func(c *Component) *string
+}{
+    {HashMD5, func(c *Component) *string { return &c.Md5 }},
+    {HashSHA1, func(c *Component) *string { return &c.Sha1 }},
+    {HashSHA256, func(c *Component) *string { return &c.Sha256 }},
+    {HashSHA384, func(c *Component) *string { return &c.Sha384 }},
+    {HashSHA512, func(c *Component) *string { return &c.Sha512 }},
+    {HashSHA3256, func(c *Component) *string { return &c.Sha3256 }},
+    {HashSHA3384, func(c *Component) *string { return &c.Sha3384 }},
+    {HashSHA3512, func(c *Component) *string { return &c.Sha3512 }},
+    {HashBLAKE2b256, func(c *Component) *string { return &c.Blake2b256 }},
+    {HashBLAKE2b384, func(c *Component) *string { return &c.Blake2b384 }},
+    {HashBLAKE2b512, func(c *Component) *string { return &c.Blake2b512 }},
+    {HashBLAKE3, func(c *Component) *string { return &c.Blake3 }},
+}
+
+// HashAlgorithms returns the names of the hash algorithms Dependency-Track
+// supports.
+func HashAlgorithms() []string {
+    names := make([]string, len(componentHashes))
+    for i, h := range componentHashes {
+        names[i] = h.algorithm
+    }
+    return names
+}
+
+// CanonicalHashAlgorithm returns the name of algorithm as listed by
+// HashAlgorithms, matching it regardless of case, hyphens and underscores:
+// "sha256" and "SHA_256" are both SHA-256. It returns "" for unknown
+// algorithms.
+func CanonicalHashAlgorithm(algorithm string) string {
+    key := hashKey(algorithm)
+    for _, h := range componentHashes {
+        if hashKey(h.algorithm) == key {
+            return h.algorithm
+        }
+    }
+    return ""
+}
+
+func hashKey(algorithm string) string {
+    return strings.NewReplacer("-", "", "_", "").Replace(strings.ToUpper(algorithm))
+}
+
+// Hash returns the component's hash for algorithm, which is matched like in
+// CanonicalHashAlgorithm. ok is false for unknown algorithms; value is empty
+// when the component has no hash of that algorithm.
+func (c *Component) Hash(algorithm string) (value string, ok bool) {
+    key := hashKey(algorithm)
+    for _, h := range componentHashes {
+        if hashKey(h.algorithm) == key {
+            return *h.field(c), true
+        }
+    }
+    return "", false
+}
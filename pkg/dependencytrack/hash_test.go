@@ -0,0 +1,47 @@
+package dependencytrack
+
+import (
+    "encoding/json"
+    "testing"
+)
+
+func TestCanonicalHashAlgorithm(t *testing.T) {
+    tests := map[string]string{
+        "SHA-256":     HashSHA256,
+        "sha256":      HashSHA256,
+        "SHA_256":     HashSHA256,
+        "sha3-512":    HashSHA3512,
+        "Blake2b_384": HashBLAKE2b384,
+        "blake3":      HashBLAKE3,
+        "md5":         HashMD5,
+        "MD4":         "",
+        "":            "",
+    }
+    for in, want := range tests {
+        if got := CanonicalHashAlgorithm(in); got != want {
+            t.Errorf("CanonicalHashAlgorithm(%q) = %q, want %q", in, got, want)
+        }
+    }
+}
+
+func TestComponentHash(t *testing.T) {
+    var c Component
+    data := `{"uuid":"c","md5":"m","sha1":"s1","sha256":"s256","sha384":"s384","sha512":"s512",` +
+        `"sha3_256":"t256","sha3_384":"t384","sha3_512":"t512",` +
+        `"blake2b_256":"b256","blake2b_384":"b384","blake2b_512":"b512","blake3":"b3"}`
+    if err := json.Unmarshal([]byte(data), &c); err != nil {
+        t.Fatal(err)
+    }
+    want := []string{"m", "s1", "s256", "s384", "s512", "t256", "t384", "t512", "b256", "b384", "b512", "b3"}
+    for i, algorithm := range HashAlgorithms() {
+        if got, ok := c.Hash(algorithm); !ok || got != want[i] {
+            t.Errorf("Hash(%s) = %q, %v, want %q", algorithm, got, ok, want[i])
+        }
+    }
+    if got, ok := (&Component{}).Hash("sha-512"); !ok || got != "" {
+        t.Errorf("Hash of a component without hashes = %q, %v, want \"\", true", got, ok)
+    }
+    if _, ok := c.Hash("MD4"); ok {
+        t.Errorf("Hash(MD4) is supported")
+    }
+}
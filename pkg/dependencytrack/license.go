@@ -0,0 +1,61 @@
+package dependencytrack
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+)
+
+// License is a license known to Dependency-Track, e.g. Apache-2.0.
+type License struct {
+    UUID      string `json:"uuid"`
+    LicenseID string `json:"licenseId,omitempty"`
+    Name      string `json:"name,omitempty"`
+}
+
+// LicenseGroup is a named set of licenses, e.g. "Copyleft", that
+// LICENSE_GROUP conditions refer to.
+type LicenseGroup struct {
+    UUID     string    `json:"uuid"`
+    Name     string    `json:"name"`
+    Licenses []License `json:"licenses,omitempty"`
+}
+
+// Contains reports whether l is in the group.
+func (g *LicenseGroup) Contains(l License) bool {
+    for _, member := range g.Licenses {
+        if member.UUID == l.UUID {
+            return true
+        }
+    }
+    return false
+}
+
+// GetLicenseGroup fetches a license group with its licenses.
+func (c *Client) GetLicenseGroup(groupUUID string) (*LicenseGroup, error) {
+    return c.GetLicenseGroupContext(context.Background(), groupUUID)
+}
+
+// GetLicenseGroupContext is like GetLicenseGroup but uses ctx for cancellation.
+func (c *Client) GetLicenseGroupContext(ctx context.Context, groupUUID string) (*LicenseGroup, error) {
+    endpoint := fmt.Sprintf("%s/api/v1/licenseGroup/%s", c.BaseURL, url.PathEscape(groupUUID))
+    req, err := c.newRequest(ctx, "GET", endpoint, nil)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := c.do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, newAPIError("get license group", resp)
+    }
+    var group LicenseGroup
+    if err := json.NewDecoder(resp.Body).Decode(&group); err != nil {
+        return nil, fmt.Errorf("failed to decode license group: %v", err)
+    }
+    return &group, nil
+}
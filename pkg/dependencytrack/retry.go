@@ -0,0 +1,164 @@
+package dependencytrack
+
+import (
+    "context"
+    "math/rand"
+    "net/http"
+    "strconv"
+    "time"
+)
+
+// RetryPolicy controls how a Client retries requests that fail with a
+// transient error: a network error, 429 Too Many Requests, or a 502, 503 or
+// 504 from the server or a load balancer in front of it.
+type RetryPolicy struct {
+    // MaxRetries is the number of retries after the first attempt.
+    // Zero disables retrying.
+    MaxRetries int
+    // MinBackoff is the delay before the first retry. It doubles on every
+    // further retry up to MaxBackoff, with random jitter applied.
+    MinBackoff time.Duration
+    MaxBackoff time.Duration
+    // IgnoreRetryAfter uses the backoff even when a response carries a
+    // Retry-After header. Otherwise the header is honored in full, and a
+    // delay longer than the request timeout or the context's deadline
+    // returns the response right away instead of waiting.
+    IgnoreRetryAfter bool
+    // RetryUpdates also retries the POST requests used by the Update
+    // methods. They are not idempotent on every server version, so they are
+    // only retried when asked for.
+    RetryUpdates bool
+}
+
+// DefaultRetryPolicy returns the policy used by NewClient.
+func DefaultRetryPolicy() RetryPolicy {
+    return RetryPolicy{
+        MaxRetries: 3,
+        MinBackoff: 500 * time.Millisecond,
+        MaxBackoff: 30 * time.Second,
+    }
+}
+
+// canRetry reports whether a request with the given method may be retried.
+func (p RetryPolicy) canRetry(method string) bool {
+    switch method {
+    case "GET", "HEAD":
+        return true
+    case "POST":
+        return p.RetryUpdates
+    }
+    return false
+}
+
+// backoff returns the delay before retry number attempt, starting at 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+    d := p.MinBackoff
+    for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+        d *= 2
+    }
+    if p.MaxBackoff > 0 && d > p.MaxBackoff {
+        d = p.MaxBackoff
+    }
+    if d <= 0 {
+        return 0
+    }
+    // Use "equal jitter": half the delay is fixed, half is random, so
+    // parallel clients spread out without retrying immediately.
+    half := d / 2
+    return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an
+// HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+    if value == "" {
+        return 0, false
+    }
+    if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+        return time.Duration(secs) * time.Second, true
+    }
+    if t, err := http.ParseTime(value); err == nil {
+        d := time.Until(t)
+        if d < 0 {
+            d = 0
+        }
+        return d, true
+    }
+    return 0, false
+}
+
+// retryableStatus reports whether a response status is worth retrying.
+func retryableStatus(code int) bool {
+    switch code {
+    case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+        return true
+    }
+    return false
+}
+
+// do sends req, retrying transient failures according to c.Retry. The
+// request body, if any, must be replayable through req.GetBody, which
+// http.NewRequest arranges for the in-memory bodies used by this package.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+    policy := c.Retry
+    if !policy.canRetry(req.Method) || (req.Body != nil && req.GetBody == nil) {
+        policy.MaxRetries = 0
+    }
+
+    ctx := req.Context()
+    for attempt := 0; ; attempt++ {
+        if attempt > 0 && req.GetBody != nil {
+            body, err := req.GetBody()
+            if err != nil {
+                return nil, err
+            }
+            req.Body = body
+        }
+
+        resp, err := c.HTTPClient.Do(req)
+        if attempt >= policy.MaxRetries || ctx.Err() != nil {
+            return resp, err
+        }
+        if err == nil && !retryableStatus(resp.StatusCode) {
+            return resp, nil
+        }
+
+        wait := policy.backoff(attempt + 1)
+        if resp != nil && !policy.IgnoreRetryAfter {
+            if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+                if c.exceedsDeadline(ctx, d) {
+                    return resp, nil
+                }
+                wait = d
+            }
+        }
+        if resp != nil {
+            resp.Body.Close()
+        }
+        if err := sleep(ctx, wait); err != nil {
+            return nil, err
+        }
+    }
+}
+
+// exceedsDeadline reports whether waiting d would outlast the request
+// timeout or the deadline of ctx.
+func (c *Client) exceedsDeadline(ctx context.Context, d time.Duration) bool {
+    if c.HTTPClient.Timeout > 0 && d > c.HTTPClient.Timeout {
+        return true
+    }
+    deadline, ok := ctx.Deadline()
+    return ok && time.Now().Add(d).After(deadline)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+    t := time.NewTimer(d)
+    defer t.Stop()
+    select {
+    case <-ctx.Done():
+        return ctx.Err()
+    case <-t.C:
+        return nil
+    }
+}
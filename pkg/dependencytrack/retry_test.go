@@ -0,0 +1,82 @@
+package dependencytrack
+
+import (
+    "net/http"
+    "testing"
+    "time"
+)
+
+func TestBackoff(t *testing.T) {
+    policy := RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+    tests := []struct {
+        attempt  int
+        min, max time.Duration
+    }{
+        {1, 50 * time.Millisecond, 100 * time.Millisecond},
+        {2, 100 * time.Millisecond, 200 * time.Millisecond},
+        {3, 200 * time.Millisecond, 400 * time.Millisecond},
+        {4, 400 * time.Millisecond, 800 * time.Millisecond},
+        {5, 500 * time.Millisecond, time.Second},
+        {40, 500 * time.Millisecond, time.Second},
+    }
+    for _, tt := range tests {
+        for i := 0; i < 100; i++ {
+            if d := policy.backoff(tt.attempt); d < tt.min || d > tt.max {
+                t.Errorf("backoff(%d) = %v, want between %v and %v", tt.attempt, d, tt.min, tt.max)
+                break
+            }
+        }
+    }
+
+    if d := (RetryPolicy{}).backoff(3); d != 0 {
+        t.Errorf("backoff without MinBackoff = %v, want 0", d)
+    }
+}
+
+func TestRetryAfter(t *testing.T) {
+    tests := []struct {
+        value  string
+        want   time.Duration
+        wantOK bool
+    }{
+        {"", 0, false},
+        {"0", 0, true},
+        {"3", 3 * time.Second, true},
+        {"120", 2 * time.Minute, true},
+        {"-1", 0, false},
+        {"soon", 0, false},
+        {time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+    }
+    for _, tt := range tests {
+        d, ok := retryAfter(tt.value)
+        if d != tt.want || ok != tt.wantOK {
+            t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.value, d, ok, tt.want, tt.wantOK)
+        }
+    }
+
+    // An HTTP date is relative to now, and only has second precision.
+    d, ok := retryAfter(time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat))
+    if !ok || d < 8*time.Second || d > 10*time.Second {
+        t.Errorf("retryAfter(now + 10s) = %v, %v", d, ok)
+    }
+}
+
+func TestCanRetry(t *testing.T) {
+    tests := []struct {
+        method       string
+        retryUpdates bool
+        want         bool
+    }{
+        {"GET", false, true},
+        {"HEAD", false, true},
+        {"POST", false, false},
+        {"POST", true, true},
+        {"PUT", true, false},
+        {"DELETE", true, false},
+    }
+    for _, tt := range tests {
+        if got := (RetryPolicy{RetryUpdates: tt.retryUpdates}).canRetry(tt.method); got != tt.want {
+            t.Errorf("canRetry(%s) with RetryUpdates %v = %v, want %v", tt.method, tt.retryUpdates, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,108 @@
+package dependencytrack
+
+import (
+    "context"
+    "crypto/tls"
+    "encoding/json"
+    "fmt"
+    "net/http"
+)
+
+// About describes a Dependency-Track server, as reported by /api/version.
+type About struct {
+    Application string `json:"application,omitempty"`
+    Version     string `json:"version"`
+    Timestamp   string `json:"timestamp,omitempty"`
+    UUID        string `json:"uuid,omitempty"`
+    // TLS is the state of the connection the version was fetched over. It
+    // is nil for plain HTTP.
+    TLS *tls.ConnectionState `json:"-"`
+}
+
+// Team is a Dependency-Track team, which API keys belong to.
+type Team struct {
+    UUID        string       `json:"uuid,omitempty"`
+    Name        string       `json:"name"`
+    Permissions []Permission `json:"permissions,omitempty"`
+}
+
+// Permission is a permission granted to a team, e.g. VIEW_PORTFOLIO.
+type Permission struct {
+    Name        string `json:"name"`
+    Description string `json:"description,omitempty"`
+}
+
+// Permissions used by dtctl commands.
+const (
+    PermissionViewPortfolio       = "VIEW_PORTFOLIO"
+    PermissionPortfolioManagement = "PORTFOLIO_MANAGEMENT"
+    PermissionPolicyManagement    = "POLICY_MANAGEMENT"
+    PermissionViewVulnerability   = "VIEW_VULNERABILITY"
+)
+
+// HasPermission reports whether the team was granted the named permission.
+func (t *Team) HasPermission(name string) bool {
+    for _, p := range t.Permissions {
+        if p.Name == name {
+            return true
+        }
+    }
+    return false
+}
+
+// GetVersion fetches the server's version. The endpoint needs no API key, so
+// it checks that the server is reachable, not that the token is valid.
+func (c *Client) GetVersion() (*About, error) {
+    return c.GetVersionContext(context.Background())
+}
+
+// GetVersionContext is like GetVersion but uses ctx for cancellation.
+func (c *Client) GetVersionContext(ctx context.Context) (*About, error) {
+    endpoint := fmt.Sprintf("%s/api/version", c.BaseURL)
+    req, err := c.newRequest(ctx, "GET", endpoint, nil)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := c.do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, newAPIError("get server version", resp)
+    }
+    var about About
+    if err := json.NewDecoder(resp.Body).Decode(&about); err != nil {
+        return nil, fmt.Errorf("failed to decode server version: %v", err)
+    }
+    about.TLS = resp.TLS
+    return &about, nil
+}
+
+// GetCurrentTeam fetches the team the client's API key belongs to, with its
+// permissions. Servers older than Dependency-Track 4.11 answer 404.
+func (c *Client) GetCurrentTeam() (*Team, error) {
+    return c.GetCurrentTeamContext(context.Background())
+}
+
+// GetCurrentTeamContext is like GetCurrentTeam but uses ctx for cancellation.
+func (c *Client) GetCurrentTeamContext(ctx context.Context) (*Team, error) {
+    endpoint := fmt.Sprintf("%s/api/v1/team/self", c.BaseURL)
+    req, err := c.newRequest(ctx, "GET", endpoint, nil)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := c.do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, newAPIError("get team of API key", resp)
+    }
+    var team Team
+    if err := json.NewDecoder(resp.Body).Decode(&team); err != nil {
+        return nil, fmt.Errorf("failed to decode team: %v", err)
+    }
+    return &team, nil
+}
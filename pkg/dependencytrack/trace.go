@@ -0,0 +1,85 @@
+package dependencytrack
+
+import (
+    "bytes"
+    "io/ioutil"
+    "net/http"
+    "time"
+)
+
+// Verbosity levels understood by TracingTransport.
+const (
+    // TraceRequests logs the method, URL, status and latency of each request.
+    TraceRequests = 1
+    // TraceHeaders also logs request and response headers.
+    TraceHeaders = 7
+    // TraceBodies also logs request and response bodies.
+    TraceBodies = 9
+)
+
+// Logger receives trace events. fields alternate between keys and values.
+type Logger interface {
+    Log(msg string, fields ...interface{})
+}
+
+// TracingTransport is an http.RoundTripper that logs every request it passes
+// on to Next. Credentials in headers are always redacted.
+type TracingTransport struct {
+    // Next performs the requests; nil means http.DefaultTransport.
+    Next   http.RoundTripper
+    Logger Logger
+    // Verbosity selects what is logged; see TraceRequests, TraceHeaders and
+    // TraceBodies. Below TraceRequests nothing is logged.
+    Verbosity int
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *TracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    next := t.Next
+    if next == nil {
+        next = http.DefaultTransport
+    }
+    if t.Verbosity < TraceRequests || t.Logger == nil {
+        return next.RoundTrip(req)
+    }
+
+    var reqBody []byte
+    if t.Verbosity >= TraceBodies {
+        var err error
+        if reqBody, err = requestBody(req); err != nil {
+            return nil, err
+        }
+    }
+
+    start := time.Now()
+    resp, err := next.RoundTrip(req)
+    fields := []interface{}{"method", req.Method, "url", req.URL.String()}
+    if err != nil {
+        fields = append(fields, "duration", time.Since(start), "error", err)
+        if t.Verbosity >= TraceHeaders {
+            fields = append(fields, "requestHeader", redactHeader(req.Header))
+        }
+        t.Logger.Log("HTTP request failed", fields...)
+        return nil, err
+    }
+
+    var respBody []byte
+    if t.Verbosity >= TraceBodies {
+        respBody, err = ioutil.ReadAll(resp.Body)
+        resp.Body.Close()
+        if err != nil {
+            return nil, err
+        }
+        resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+    }
+
+    fields = append(fields, "status", resp.StatusCode, "duration", time.Since(start))
+    if t.Verbosity >= TraceHeaders {
+        fields = append(fields, "requestHeader", redactHeader(req.Header), "responseHeader", redactHeader(resp.Header))
+    }
+    if t.Verbosity >= TraceBodies {
+        fields = append(fields, "requestBody", string(reqBody), "responseBody", string(respBody))
+    }
+    t.Logger.Log("HTTP request", fields...)
+    return resp, nil
+}
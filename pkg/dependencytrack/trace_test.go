@@ -0,0 +1,56 @@
+package dependencytrack_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "dtctl/pkg/dependencytrack"
+    "dtctl/pkg/dependencytrack/fake"
+)
+
+// recordingLogger keeps every logged line as text.
+type recordingLogger struct {
+    lines []string
+}
+
+func (l *recordingLogger) Log(msg string, fields ...interface{}) {
+    l.lines = append(l.lines, msg+" "+fmt.Sprint(fields...))
+}
+
+func TestTracingTransport(t *testing.T) {
+    srv := fake.NewServer(fake.Fixtures{Projects: []dependencytrack.Project{{Name: "one", UUID: "p1"}}})
+    defer srv.Close()
+
+    tests := []struct {
+        verbosity int
+        want      []string
+        notWant   []string
+    }{
+        {0, nil, []string{"HTTP request"}},
+        {dependencytrack.TraceRequests, []string{"GET", "/api/v1/project", "200"}, []string{"requestHeader", "responseBody"}},
+        {dependencytrack.TraceHeaders, []string{"requestHeader", "REDACTED"}, []string{fake.Token, "responseBody"}},
+        {dependencytrack.TraceBodies, []string{"responseBody", `"uuid":"p1"`}, []string{fake.Token}},
+    }
+    for _, tt := range tests {
+        logger := &recordingLogger{}
+        client := srv.Client()
+        client.HTTPClient.Transport = &dependencytrack.TracingTransport{Logger: logger, Verbosity: tt.verbosity}
+        projects, err := client.GetProjects()
+        if err != nil || len(projects) != 1 {
+            t.Fatalf("verbosity %d: got %v, %v", tt.verbosity, projects, err)
+        }
+
+        log := strings.Join(logger.lines, "\n")
+        for _, s := range tt.want {
+            if !strings.Contains(log, s) {
+                t.Errorf("verbosity %d: log is missing %q:\n%s", tt.verbosity, s, log)
+            }
+        }
+        for _, s := range tt.notWant {
+            if strings.Contains(log, s) {
+                t.Errorf("verbosity %d: log contains %q:\n%s", tt.verbosity, s, log)
+            }
+        }
+    }
+}
@@ -0,0 +1,108 @@
+package dependencytrack
+
+import (
+    "crypto/tls"
+    "crypto/x509"
+    "fmt"
+    "io/ioutil"
+    "net/http"
+    "net/url"
+)
+
+// TransportConfig describes how a Client connects to a server that needs a
+// private CA, client certificates or a proxy. Certificates and keys are PEM
+// encoded and given either as a file or inline; inline data wins when both
+// are set.
+type TransportConfig struct {
+    // CAFile and CAData hold extra certificate authorities trusted in
+    // addition to the system's.
+    CAFile string
+    CAData []byte
+    // CertFile/CertData and KeyFile/KeyData hold the client certificate and
+    // key used for mutual TLS. Both must be given or neither.
+    CertFile string
+    CertData []byte
+    KeyFile  string
+    KeyData  []byte
+    // InsecureSkipVerify disables verification of the server's certificate.
+    InsecureSkipVerify bool
+    // ServerName overrides the name the server's certificate is checked
+    // against, for servers reached through an address not in it.
+    ServerName string
+    // ProxyURL sends every request through this proxy. When empty the
+    // HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables apply.
+    ProxyURL string
+}
+
+// NewTransport returns an http.Transport configured by cfg.
+func NewTransport(cfg TransportConfig) (*http.Transport, error) {
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+
+    if cfg.ProxyURL != "" {
+        proxy, err := url.Parse(cfg.ProxyURL)
+        if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+            return nil, fmt.Errorf("invalid proxy URL %q", cfg.ProxyURL)
+        }
+        transport.Proxy = http.ProxyURL(proxy)
+    }
+
+    tlsConfig := &tls.Config{
+        InsecureSkipVerify: cfg.InsecureSkipVerify,
+        ServerName:         cfg.ServerName,
+    }
+
+    caData, err := pemData("certificate authority", cfg.CAFile, cfg.CAData)
+    if err != nil {
+        return nil, err
+    }
+    if caData != nil {
+        if cfg.InsecureSkipVerify {
+            return nil, fmt.Errorf("a certificate authority cannot be used together with insecure-skip-tls-verify")
+        }
+        pool, err := x509.SystemCertPool()
+        if err != nil || pool == nil {
+            pool = x509.NewCertPool()
+        }
+        if !pool.AppendCertsFromPEM(caData) {
+            return nil, fmt.Errorf("no valid PEM certificates found in certificate authority")
+        }
+        tlsConfig.RootCAs = pool
+    }
+
+    certData, err := pemData("client certificate", cfg.CertFile, cfg.CertData)
+    if err != nil {
+        return nil, err
+    }
+    keyData, err := pemData("client key", cfg.KeyFile, cfg.KeyData)
+    if err != nil {
+        return nil, err
+    }
+    if (certData == nil) != (keyData == nil) {
+        return nil, fmt.Errorf("a client certificate and a client key must be given together")
+    }
+    if certData != nil {
+        cert, err := tls.X509KeyPair(certData, keyData)
+        if err != nil {
+            return nil, fmt.Errorf("invalid client certificate or key: %v", err)
+        }
+        tlsConfig.Certificates = []tls.Certificate{cert}
+    }
+
+    transport.TLSClientConfig = tlsConfig
+    return transport, nil
+}
+
+// pemData returns inline data if set, else the contents of file, else nil.
+func pemData(what, file string, data []byte) ([]byte, error) {
+    if len(data) > 0 {
+        return data, nil
+    }
+    if file == "" {
+        return nil, nil
+    }
+    data, err := ioutil.ReadFile(file)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read %s: %v", what, err)
+    }
+    return data, nil
+}
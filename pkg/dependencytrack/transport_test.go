@@ -0,0 +1,150 @@
+package dependencytrack
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/pem"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func TestNewTransportTLS(t *testing.T) {
+    srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer srv.Close()
+    ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+    tests := []struct {
+        name    string
+        cfg     TransportConfig
+        wantErr bool
+    }{
+        {"system roots only", TransportConfig{}, true},
+        {"custom CA", TransportConfig{CAData: ca}, false},
+        {"insecure", TransportConfig{InsecureSkipVerify: true}, false},
+        // The httptest certificate is valid for example.com and 127.0.0.1.
+        {"matching server name", TransportConfig{CAData: ca, ServerName: "example.com"}, false},
+        {"wrong server name", TransportConfig{CAData: ca, ServerName: "dependency-track.test"}, true},
+    }
+    for _, tt := range tests {
+        transport, err := NewTransport(tt.cfg)
+        if err != nil {
+            t.Fatalf("%s: %v", tt.name, err)
+        }
+        resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+        if err == nil {
+            resp.Body.Close()
+        }
+        if (err != nil) != tt.wantErr {
+            t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+        }
+    }
+}
+
+func TestNewTransportClientCertificate(t *testing.T) {
+    certPEM, keyPEM, cert := selfSignedCertificate(t)
+    clientCAs := x509.NewCertPool()
+    clientCAs.AddCert(cert)
+
+    srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+    srv.StartTLS()
+    defer srv.Close()
+
+    for _, withCert := range []bool{false, true} {
+        cfg := TransportConfig{InsecureSkipVerify: true}
+        if withCert {
+            cfg.CertData, cfg.KeyData = certPEM, keyPEM
+        }
+        transport, err := NewTransport(cfg)
+        if err != nil {
+            t.Fatal(err)
+        }
+        resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+        if err == nil {
+            resp.Body.Close()
+        }
+        if (err == nil) != withCert {
+            t.Errorf("with client certificate %v: got error %v", withCert, err)
+        }
+    }
+}
+
+func TestNewTransportProxy(t *testing.T) {
+    var proxied string
+    proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        proxied = r.URL.String()
+    }))
+    defer proxy.Close()
+
+    transport, err := NewTransport(TransportConfig{ProxyURL: proxy.URL})
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp, err := (&http.Client{Transport: transport}).Get("http://dependency-track.test/api/v1/project")
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    if proxied != "http://dependency-track.test/api/v1/project" {
+        t.Errorf("proxy received %q", proxied)
+    }
+}
+
+func TestNewTransportInvalid(t *testing.T) {
+    certPEM, keyPEM, _ := selfSignedCertificate(t)
+    for name, cfg := range map[string]TransportConfig{
+        "certificate without key": {CertData: certPEM},
+        "key without certificate": {KeyData: keyPEM},
+        "mismatched pair":         {CertData: certPEM, KeyData: certPEM},
+        "CA that is not PEM":      {CAData: []byte("not a certificate")},
+        "missing CA file":         {CAFile: "testdata/does-not-exist.pem"},
+        "CA and insecure":         {CAData: certPEM, InsecureSkipVerify: true},
+        "proxy without scheme":    {ProxyURL: "proxy.example.com:3128"},
+    } {
+        if _, err := NewTransport(cfg); err == nil {
+            t.Errorf("%s: expected an error", name)
+        }
+    }
+}
+
+// selfSignedCertificate returns a PEM certificate and key usable for client
+// authentication.
+func selfSignedCertificate(t *testing.T) ([]byte, []byte, *x509.Certificate) {
+    t.Helper()
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    template := &x509.Certificate{
+        SerialNumber:          big.NewInt(1),
+        Subject:               pkix.Name{CommonName: "dtctl"},
+        NotBefore:             time.Now().Add(-time.Hour),
+        NotAfter:              time.Now().Add(time.Hour),
+        KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+        ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+    }
+    der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+    keyDER, err := x509.MarshalECPrivateKey(key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+    keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+    return certPEM, keyPEM, cert
+}
@@ -0,0 +1,200 @@
+package dependencytrack
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/url"
+    "strconv"
+)
+
+// Vulnerability severities, from most to least severe.
+const (
+    SeverityCritical   = "CRITICAL"
+    SeverityHigh       = "HIGH"
+    SeverityMedium     = "MEDIUM"
+    SeverityLow        = "LOW"
+    SeverityInfo       = "INFO"
+    SeverityUnassigned = "UNASSIGNED"
+)
+
+// Vulnerability is a vulnerability known to Dependency-Track, e.g.
+// CVE-2021-44228.
+type Vulnerability struct {
+    UUID     string `json:"uuid"`
+    VulnID   string `json:"vulnId"`
+    Source   string `json:"source,omitempty"`
+    Title    string `json:"title,omitempty"`
+    Severity string `json:"severity,omitempty"`
+    // CWEID is the first of CWEs, as sent in findings by older servers.
+    CWEID *int  `json:"cweId,omitempty"`
+    CWEs  []CWE `json:"cwes,omitempty"`
+    // EPSSScore is the probability of exploitation from 0 to 1; nil when
+    // the vulnerability has no EPSS score.
+    EPSSScore      *float64 `json:"epssScore,omitempty"`
+    EPSSPercentile *float64 `json:"epssPercentile,omitempty"`
+}
+
+// CWE is a weakness a vulnerability is classified as, e.g. CWE-79.
+type CWE struct {
+    CWEID int    `json:"cweId"`
+    Name  string `json:"name,omitempty"`
+}
+
+// CWEIDs returns the IDs of the weaknesses of v.
+func (v Vulnerability) CWEIDs() []int {
+    var ids []int
+    seen := map[int]bool{}
+    for _, c := range v.CWEs {
+        if !seen[c.CWEID] {
+            seen[c.CWEID] = true
+            ids = append(ids, c.CWEID)
+        }
+    }
+    if v.CWEID != nil && !seen[*v.CWEID] {
+        ids = append(ids, *v.CWEID)
+    }
+    return ids
+}
+
+// Finding is a vulnerability of a component in a project, with the analysis
+// of whether it applies.
+type Finding struct {
+    Component     FindingComponent `json:"component"`
+    Vulnerability Vulnerability    `json:"vulnerability"`
+    Analysis      Analysis         `json:"analysis"`
+    // Matrix identifies the finding as PROJECT:COMPONENT:VULNERABILITY UUIDs.
+    Matrix string `json:"matrix,omitempty"`
+}
+
+// FindingComponent is the component of a finding.
+type FindingComponent struct {
+    UUID    string `json:"uuid"`
+    Group   string `json:"group,omitempty"`
+    Name    string `json:"name"`
+    Version string `json:"version,omitempty"`
+    PURL    string `json:"purl,omitempty"`
+    // Project is the UUID of the component's project.
+    Project string `json:"project,omitempty"`
+}
+
+// Analysis is the outcome of auditing a finding.
+type Analysis struct {
+    State string `json:"state,omitempty"`
+    // IsSuppressed is set for findings that were suppressed, e.g. as false
+    // positives; they do not count towards policy violations.
+    IsSuppressed bool `json:"isSuppressed"`
+}
+
+// GetComponentVulnerabilities fetches the vulnerabilities of a component.
+// Vulnerabilities whose findings were suppressed are included only if
+// suppressed is true.
+func (c *Client) GetComponentVulnerabilities(componentUUID string, suppressed bool) ([]Vulnerability, error) {
+    return c.GetComponentVulnerabilitiesContext(context.Background(), componentUUID, suppressed)
+}
+
+// GetComponentVulnerabilitiesContext is like GetComponentVulnerabilities but uses ctx for cancellation.
+func (c *Client) GetComponentVulnerabilitiesContext(ctx context.Context, componentUUID string, suppressed bool) ([]Vulnerability, error) {
+    var all []Vulnerability
+    err := c.WalkComponentVulnerabilitiesContext(ctx, componentUUID, suppressed, func(page []Vulnerability) error {
+        all = append(all, page...)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return all, nil
+}
+
+// WalkComponentVulnerabilities calls fn with each page of vulnerabilities of a component.
+func (c *Client) WalkComponentVulnerabilities(componentUUID string, suppressed bool, fn func([]Vulnerability) error) error {
+    return c.WalkComponentVulnerabilitiesContext(context.Background(), componentUUID, suppressed, fn)
+}
+
+// WalkComponentVulnerabilitiesContext is like WalkComponentVulnerabilities but uses ctx for cancellation.
+func (c *Client) WalkComponentVulnerabilitiesContext(ctx context.Context, componentUUID string, suppressed bool, fn func([]Vulnerability) error) error {
+    endpoint := fmt.Sprintf("%s/api/v1/vulnerability/component/%s?suppressed=%s", c.BaseURL, url.PathEscape(componentUUID), strconv.FormatBool(suppressed))
+    return c.walkVulnerabilities(ctx, "get vulnerabilities of component", endpoint, fn)
+}
+
+// GetProjectVulnerabilities fetches the vulnerabilities of the components of
+// a project. Vulnerabilities whose findings were suppressed are included only
+// if suppressed is true.
+func (c *Client) GetProjectVulnerabilities(projectUUID string, suppressed bool) ([]Vulnerability, error) {
+    return c.GetProjectVulnerabilitiesContext(context.Background(), projectUUID, suppressed)
+}
+
+// GetProjectVulnerabilitiesContext is like GetProjectVulnerabilities but uses ctx for cancellation.
+func (c *Client) GetProjectVulnerabilitiesContext(ctx context.Context, projectUUID string, suppressed bool) ([]Vulnerability, error) {
+    var all []Vulnerability
+    err := c.WalkProjectVulnerabilitiesContext(ctx, projectUUID, suppressed, func(page []Vulnerability) error {
+        all = append(all, page...)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return all, nil
+}
+
+// WalkProjectVulnerabilities calls fn with each page of vulnerabilities of a project.
+func (c *Client) WalkProjectVulnerabilities(projectUUID string, suppressed bool, fn func([]Vulnerability) error) error {
+    return c.WalkProjectVulnerabilitiesContext(context.Background(), projectUUID, suppressed, fn)
+}
+
+// WalkProjectVulnerabilitiesContext is like WalkProjectVulnerabilities but uses ctx for cancellation.
+func (c *Client) WalkProjectVulnerabilitiesContext(ctx context.Context, projectUUID string, suppressed bool, fn func([]Vulnerability) error) error {
+    endpoint := fmt.Sprintf("%s/api/v1/vulnerability/project/%s?suppressed=%s", c.BaseURL, url.PathEscape(projectUUID), strconv.FormatBool(suppressed))
+    return c.walkVulnerabilities(ctx, "get vulnerabilities of project", endpoint, fn)
+}
+
+func (c *Client) walkVulnerabilities(ctx context.Context, op, endpoint string, fn func([]Vulnerability) error) error {
+    return c.list(ctx, op, endpoint, func(body io.Reader) (int, error) {
+        var page []Vulnerability
+        if err := json.NewDecoder(body).Decode(&page); err != nil {
+            return 0, err
+        }
+        if len(page) == 0 {
+            return 0, nil
+        }
+        return len(page), fn(page)
+    })
+}
+
+// GetProjectFindings fetches the findings of a project: every vulnerability
+// of every component, with its analysis. Suppressed findings are included
+// only if suppressed is true.
+func (c *Client) GetProjectFindings(projectUUID string, suppressed bool) ([]Finding, error) {
+    return c.GetProjectFindingsContext(context.Background(), projectUUID, suppressed)
+}
+
+// GetProjectFindingsContext is like GetProjectFindings but uses ctx for cancellation.
+func (c *Client) GetProjectFindingsContext(ctx context.Context, projectUUID string, suppressed bool) ([]Finding, error) {
+    endpoint := fmt.Sprintf("%s/api/v1/finding/project/%s?suppressed=%s", c.BaseURL, url.PathEscape(projectUUID), strconv.FormatBool(suppressed))
+    var all []Finding
+    err := c.list(ctx, "get findings of project", endpoint, func(body io.Reader) (int, error) {
+        var page []Finding
+        if err := json.NewDecoder(body).Decode(&page); err != nil {
+            return 0, err
+        }
+        all = append(all, page...)
+        return len(page), nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return all, nil
+}
+
+// ComponentFindings returns the findings of the component with the given
+// UUID.
+func ComponentFindings(findings []Finding, componentUUID string) []Finding {
+    var matched []Finding
+    for _, f := range findings {
+        if f.Component.UUID == componentUUID {
+            matched = append(matched, f)
+        }
+    }
+    return matched
+}
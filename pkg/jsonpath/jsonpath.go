@@ -0,0 +1,702 @@
+// Package jsonpath implements the JSONPath template syntax used by kubectl
+// for `-o jsonpath=...` output, evaluated against decoded JSON values
+// (map[string]interface{}, []interface{}, string, json.Number or float64,
+// bool and nil).
+//
+// A template mixes literal text with expressions in braces:
+//
+//    {.items[*].uuid}
+//    {range .items[*]}{.name}{"\t"}{.uuid}{"\n"}{end}
+//
+// Supported path syntax: .field, ['field'], [n], [-n], [start:end], [*],
+// .*, ..field (recursive descent) and filters such as [?(@.name=="x")] with
+// ==, !=, <, <=, >, >= or a bare @.field existence check. A path starting
+// with $ is evaluated from the root document, any other path from the
+// current element.
+//
+// Like kubectl, a template fails on a field that is missing or an index
+// outside its array unless AllowMissingKeys is set. Recursive descent,
+// wildcards, slices and filters may select nothing without failing.
+package jsonpath
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// Path is a compiled JSONPath expression such as .items[*].name.
+type Path struct {
+    text  string
+    root  bool
+    steps []step
+}
+
+// Template is a parsed JSONPath template.
+type Template struct {
+    nodes            []node
+    allowMissingKeys bool
+}
+
+type stepKind int
+
+const (
+    stepField stepKind = iota
+    stepWildcard
+    stepIndex
+    stepSlice
+    stepRecursive
+    stepFilter
+)
+
+type step struct {
+    kind stepKind
+    // name is the field of stepField and stepRecursive; an empty name makes
+    // stepRecursive select every descendant.
+    name string
+    // index, start and end serve stepIndex and stepSlice.
+    index, start, end int
+    hasStart, hasEnd  bool
+    filter            *filter
+}
+
+type filter struct {
+    left  *Path
+    op    string
+    right interface{}
+}
+
+type nodeKind int
+
+const (
+    nodeText nodeKind = iota
+    nodePath
+    nodeRange
+)
+
+type node struct {
+    kind nodeKind
+    text string
+    path *Path
+    // body holds the nodes between {range ...} and {end}.
+    body []node
+}
+
+// Compile parses a single path expression. Surrounding braces are optional.
+func Compile(expr string) (*Path, error) {
+    text := strings.TrimSpace(expr)
+    if strings.HasPrefix(text, "{") && strings.HasSuffix(text, "}") {
+        text = strings.TrimSpace(text[1 : len(text)-1])
+    }
+    p, err := parsePath(text)
+    if err != nil {
+        return nil, fmt.Errorf("invalid JSONPath %q: %v", expr, err)
+    }
+    return p, nil
+}
+
+// String returns the expression the path was compiled from.
+func (p *Path) String() string {
+    return p.text
+}
+
+// Find returns every value the path selects in data. Missing fields select
+// nothing rather than failing.
+func (p *Path) Find(data interface{}) []interface{} {
+    return p.find(data, data)
+}
+
+func (p *Path) find(root, current interface{}) []interface{} {
+    values, _ := p.eval(root, current, false)
+    return values
+}
+
+// eval is find, except that with strict set a missing field or an index
+// outside its array is an error instead of selecting nothing.
+func (p *Path) eval(root, current interface{}, strict bool) ([]interface{}, error) {
+    values := []interface{}{current}
+    if p.root {
+        values = []interface{}{root}
+    }
+    for _, s := range p.steps {
+        var next []interface{}
+        for _, v := range values {
+            if strict {
+                if err := s.check(v); err != nil {
+                    return nil, err
+                }
+            }
+            next = append(next, s.apply(root, v)...)
+        }
+        values = next
+    }
+    return values, nil
+}
+
+// Parse parses a template.
+func Parse(text string) (*Template, error) {
+    nodes, rest, err := parseNodes(text, false)
+    if err != nil {
+        return nil, fmt.Errorf("invalid JSONPath template %q: %v", text, err)
+    }
+    if rest != "" {
+        return nil, fmt.Errorf("invalid JSONPath template %q: unexpected {end}", text)
+    }
+    return &Template{nodes: nodes}, nil
+}
+
+// AllowMissingKeys sets whether Execute prints nothing for a missing field or
+// an index outside its array rather than failing. It returns t.
+func (t *Template) AllowMissingKeys(allow bool) *Template {
+    t.allowMissingKeys = allow
+    return t
+}
+
+// Execute writes the template applied to data to w. Multiple values selected
+// by one expression are separated by spaces.
+func (t *Template) Execute(w io.Writer, data interface{}) error {
+    return execute(w, t.nodes, data, data, !t.allowMissingKeys)
+}
+
+func execute(w io.Writer, nodes []node, root, current interface{}, strict bool) error {
+    for _, n := range nodes {
+        switch n.kind {
+        case nodeText:
+            if _, err := io.WriteString(w, n.text); err != nil {
+                return err
+            }
+        case nodePath:
+            values, err := n.path.eval(root, current, strict)
+            if err != nil {
+                return fmt.Errorf("%s: %v", n.path, err)
+            }
+            parts := make([]string, len(values))
+            for i, v := range values {
+                parts[i] = Format(v)
+            }
+            if _, err := io.WriteString(w, strings.Join(parts, " ")); err != nil {
+                return err
+            }
+        case nodeRange:
+            values, err := n.path.eval(root, current, strict)
+            if err != nil {
+                return fmt.Errorf("%s: %v", n.path, err)
+            }
+            for _, v := range values {
+                if err := execute(w, n.body, root, v, strict); err != nil {
+                    return err
+                }
+            }
+        }
+    }
+    return nil
+}
+
+// Format renders a selected value: strings as is, scalars in their JSON
+// form, nil as an empty string and objects or arrays as compact JSON.
+func Format(v interface{}) string {
+    switch val := v.(type) {
+    case nil:
+        return ""
+    case string:
+        return val
+    case json.Number:
+        return val.String()
+    case float64:
+        return strconv.FormatFloat(val, 'f', -1, 64)
+    case bool:
+        return strconv.FormatBool(val)
+    }
+    data, err := json.Marshal(v)
+    if err != nil {
+        return fmt.Sprint(v)
+    }
+    return string(data)
+}
+
+// parseNodes parses template text until the end of input or, inside a
+// range, until the matching {end}. It returns the text following {end}.
+func parseNodes(text string, inRange bool) ([]node, string, error) {
+    var nodes []node
+    for text != "" {
+        open := strings.IndexByte(text, '{')
+        if open < 0 {
+            nodes = append(nodes, node{kind: nodeText, text: text})
+            text = ""
+            break
+        }
+        if open > 0 {
+            nodes = append(nodes, node{kind: nodeText, text: text[:open]})
+        }
+        closeAt, err := matchBrace(text, open)
+        if err != nil {
+            return nil, "", err
+        }
+        expr := strings.TrimSpace(text[open+1 : closeAt])
+        text = text[closeAt+1:]
+
+        switch {
+        case expr == "end":
+            if !inRange {
+                return nodes, "{end}" + text, nil
+            }
+            return nodes, text, nil
+        case strings.HasPrefix(expr, "range "):
+            p, err := parsePath(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+            if err != nil {
+                return nil, "", err
+            }
+            body, rest, err := parseNodes(text, true)
+            if err != nil {
+                return nil, "", err
+            }
+            nodes = append(nodes, node{kind: nodeRange, path: p, body: body})
+            text = rest
+            continue
+        case strings.HasPrefix(expr, `"`) || strings.HasPrefix(expr, "'"):
+            lit, err := unquote(expr)
+            if err != nil {
+                return nil, "", err
+            }
+            nodes = append(nodes, node{kind: nodeText, text: lit})
+        default:
+            p, err := parsePath(expr)
+            if err != nil {
+                return nil, "", err
+            }
+            nodes = append(nodes, node{kind: nodePath, path: p})
+        }
+    }
+    if inRange {
+        return nil, "", fmt.Errorf("{range} without {end}")
+    }
+    return nodes, "", nil
+}
+
+// matchBrace returns the index of the brace closing the one at open,
+// skipping braces inside quoted strings.
+func matchBrace(text string, open int) (int, error) {
+    depth := 0
+    var quote byte
+    for i := open; i < len(text); i++ {
+        c := text[i]
+        switch {
+        case quote != 0:
+            if c == '\\' {
+                i++
+            } else if c == quote {
+                quote = 0
+            }
+        case c == '"' || c == '\'':
+            quote = c
+        case c == '{':
+            depth++
+        case c == '}':
+            depth--
+            if depth == 0 {
+                return i, nil
+            }
+        }
+    }
+    return 0, fmt.Errorf("unclosed {")
+}
+
+func unquote(s string) (string, error) {
+    if strings.HasPrefix(s, "'") {
+        if len(s) < 2 || !strings.HasSuffix(s, "'") {
+            return "", fmt.Errorf("unterminated string %s", s)
+        }
+        return s[1 : len(s)-1], nil
+    }
+    v, err := strconv.Unquote(s)
+    if err != nil {
+        return "", fmt.Errorf("invalid string %s", s)
+    }
+    return v, nil
+}
+
+// parsePath parses a path without surrounding braces.
+func parsePath(text string) (*Path, error) {
+    p := &Path{text: text}
+    rest := text
+    switch {
+    case strings.HasPrefix(rest, "$"):
+        p.root = true
+        rest = rest[1:]
+    case strings.HasPrefix(rest, "@"):
+        rest = rest[1:]
+    }
+    if rest == "." {
+        return p, nil
+    }
+
+    for rest != "" {
+        switch {
+        case strings.HasPrefix(rest, ".."):
+            rest = rest[2:]
+            name, after := readName(rest)
+            if name == "" && !strings.HasPrefix(after, "[") {
+                return nil, fmt.Errorf("expected field name after ..")
+            }
+            p.steps = append(p.steps, step{kind: stepRecursive, name: name})
+            rest = after
+        case strings.HasPrefix(rest, "."):
+            rest = rest[1:]
+            if strings.HasPrefix(rest, "*") {
+                p.steps = append(p.steps, step{kind: stepWildcard})
+                rest = rest[1:]
+                continue
+            }
+            name, after := readName(rest)
+            if name == "" {
+                return nil, fmt.Errorf("expected field name at %q", rest)
+            }
+            p.steps = append(p.steps, step{kind: stepField, name: name})
+            rest = after
+        case strings.HasPrefix(rest, "["):
+            end, err := matchBracket(rest)
+            if err != nil {
+                return nil, err
+            }
+            s, err := parseBracket(strings.TrimSpace(rest[1:end]))
+            if err != nil {
+                return nil, err
+            }
+            p.steps = append(p.steps, s)
+            rest = rest[end+1:]
+        default:
+            // A leading bare name, as in "items[0]" or "name".
+            name, after := readName(rest)
+            if name == "" || len(p.steps) > 0 {
+                return nil, fmt.Errorf("unexpected %q", rest)
+            }
+            p.steps = append(p.steps, step{kind: stepField, name: name})
+            rest = after
+        }
+    }
+    return p, nil
+}
+
+func readName(s string) (string, string) {
+    i := 0
+    for i < len(s) {
+        c := s[i]
+        if c == '.' || c == '[' || c == ' ' || c == '=' || c == '!' || c == '<' || c == '>' || c == ')' {
+            break
+        }
+        i++
+    }
+    return s[:i], s[i:]
+}
+
+func matchBracket(s string) (int, error) {
+    depth := 0
+    var quote byte
+    for i := 0; i < len(s); i++ {
+        c := s[i]
+        switch {
+        case quote != 0:
+            if c == '\\' {
+                i++
+            } else if c == quote {
+                quote = 0
+            }
+        case c == '"' || c == '\'':
+            quote = c
+        case c == '[':
+            depth++
+        case c == ']':
+            depth--
+            if depth == 0 {
+                return i, nil
+            }
+        }
+    }
+    return 0, fmt.Errorf("unclosed [")
+}
+
+func parseBracket(inner string) (step, error) {
+    switch {
+    case inner == "*":
+        return step{kind: stepWildcard}, nil
+    case strings.HasPrefix(inner, "'") || strings.HasPrefix(inner, `"`):
+        name, err := unquote(inner)
+        if err != nil {
+            return step{}, err
+        }
+        return step{kind: stepField, name: name}, nil
+    case strings.HasPrefix(inner, "?(") && strings.HasSuffix(inner, ")"):
+        f, err := parseFilter(strings.TrimSpace(inner[2 : len(inner)-1]))
+        if err != nil {
+            return step{}, err
+        }
+        return step{kind: stepFilter, filter: f}, nil
+    case strings.Contains(inner, ":"):
+        parts := strings.SplitN(inner, ":", 2)
+        s := step{kind: stepSlice}
+        if v := strings.TrimSpace(parts[0]); v != "" {
+            n, err := strconv.Atoi(v)
+            if err != nil {
+                return step{}, fmt.Errorf("invalid slice start %q", v)
+            }
+            s.start, s.hasStart = n, true
+        }
+        if v := strings.TrimSpace(parts[1]); v != "" {
+            n, err := strconv.Atoi(v)
+            if err != nil {
+                return step{}, fmt.Errorf("invalid slice end %q", v)
+            }
+            s.end, s.hasEnd = n, true
+        }
+        return s, nil
+    }
+    n, err := strconv.Atoi(inner)
+    if err != nil {
+        return step{}, fmt.Errorf("invalid index %q", inner)
+    }
+    return step{kind: stepIndex, index: n}, nil
+}
+
+var filterOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func parseFilter(expr string) (*filter, error) {
+    for _, op := range filterOps {
+        i := strings.Index(expr, op)
+        if i < 0 {
+            continue
+        }
+        left, err := parsePath(strings.TrimSpace(expr[:i]))
+        if err != nil {
+            return nil, err
+        }
+        right, err := parseLiteral(strings.TrimSpace(expr[i+len(op):]))
+        if err != nil {
+            return nil, err
+        }
+        return &filter{left: left, op: op, right: right}, nil
+    }
+    left, err := parsePath(expr)
+    if err != nil {
+        return nil, err
+    }
+    return &filter{left: left}, nil
+}
+
+func parseLiteral(s string) (interface{}, error) {
+    switch {
+    case s == "true":
+        return true, nil
+    case s == "false":
+        return false, nil
+    case s == "null":
+        return nil, nil
+    case strings.HasPrefix(s, "'") || strings.HasPrefix(s, `"`):
+        return unquote(s)
+    }
+    f, err := strconv.ParseFloat(s, 64)
+    if err != nil {
+        return nil, fmt.Errorf("invalid filter value %q", s)
+    }
+    return f, nil
+}
+
+func (s step) apply(root, v interface{}) []interface{} {
+    switch s.kind {
+    case stepField:
+        if m, ok := v.(map[string]interface{}); ok {
+            if child, ok := m[s.name]; ok {
+                return []interface{}{child}
+            }
+        }
+        return nil
+    case stepWildcard:
+        return children(v)
+    case stepIndex:
+        arr, ok := v.([]interface{})
+        if !ok {
+            return nil
+        }
+        i := s.index
+        if i < 0 {
+            i += len(arr)
+        }
+        if i < 0 || i >= len(arr) {
+            return nil
+        }
+        return []interface{}{arr[i]}
+    case stepSlice:
+        arr, ok := v.([]interface{})
+        if !ok {
+            return nil
+        }
+        start, end := 0, len(arr)
+        if s.hasStart {
+            start = clampIndex(s.start, len(arr))
+        }
+        if s.hasEnd {
+            end = clampIndex(s.end, len(arr))
+        }
+        if start >= end {
+            return nil
+        }
+        return append([]interface{}(nil), arr[start:end]...)
+    case stepRecursive:
+        var out []interface{}
+        descend(v, func(d interface{}) {
+            if s.name == "" {
+                out = append(out, d)
+                return
+            }
+            if m, ok := d.(map[string]interface{}); ok {
+                if child, ok := m[s.name]; ok {
+                    out = append(out, child)
+                }
+            }
+        })
+        return out
+    case stepFilter:
+        var out []interface{}
+        for _, c := range children(v) {
+            if s.filter.match(root, c) {
+                out = append(out, c)
+            }
+        }
+        return out
+    }
+    return nil
+}
+
+// check reports why a field or index step selects nothing in v, for the
+// strict evaluation of templates.
+func (s step) check(v interface{}) error {
+    switch s.kind {
+    case stepField:
+        if m, ok := v.(map[string]interface{}); ok {
+            if _, ok := m[s.name]; ok {
+                return nil
+            }
+        }
+        return fmt.Errorf("%s is not found", s.name)
+    case stepIndex:
+        arr, ok := v.([]interface{})
+        if !ok {
+            return fmt.Errorf("[%d] is not found", s.index)
+        }
+        i := s.index
+        if i < 0 {
+            i += len(arr)
+        }
+        if i < 0 || i >= len(arr) {
+            return fmt.Errorf("array index out of bounds: index %d, length %d", s.index, len(arr))
+        }
+    }
+    return nil
+}
+
+func clampIndex(i, n int) int {
+    if i < 0 {
+        i += n
+    }
+    if i < 0 {
+        return 0
+    }
+    if i > n {
+        return n
+    }
+    return i
+}
+
+// children returns the elements of an array or the values of an object in
+// key order.
+func children(v interface{}) []interface{} {
+    switch val := v.(type) {
+    case []interface{}:
+        return append([]interface{}(nil), val...)
+    case map[string]interface{}:
+        keys := make([]string, 0, len(val))
+        for k := range val {
+            keys = append(keys, k)
+        }
+        sort.Strings(keys)
+        out := make([]interface{}, len(keys))
+        for i, k := range keys {
+            out[i] = val[k]
+        }
+        return out
+    }
+    return nil
+}
+
+func descend(v interface{}, visit func(interface{})) {
+    visit(v)
+    for _, c := range children(v) {
+        descend(c, visit)
+    }
+}
+
+func (f *filter) match(root, v interface{}) bool {
+    values := f.left.find(root, v)
+    if f.op == "" {
+        return len(values) > 0 && values[0] != nil && values[0] != false
+    }
+    for _, left := range values {
+        if compare(left, f.op, f.right) {
+            return true
+        }
+    }
+    return false
+}
+
+func compare(left interface{}, op string, right interface{}) bool {
+    if r, ok := right.(float64); ok {
+        l, ok := toFloat(left)
+        if !ok {
+            return op == "!="
+        }
+        switch op {
+        case "==":
+            return l == r
+        case "!=":
+            return l != r
+        case "<":
+            return l < r
+        case "<=":
+            return l <= r
+        case ">":
+            return l > r
+        case ">=":
+            return l >= r
+        }
+        return false
+    }
+    l, r := Format(left), Format(right)
+    switch op {
+    case "==":
+        return l == r
+    case "!=":
+        return l != r
+    case "<":
+        return l < r
+    case "<=":
+        return l <= r
+    case ">":
+        return l > r
+    case ">=":
+        return l >= r
+    }
+    return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+    switch n := v.(type) {
+    case float64:
+        return n, true
+    case json.Number:
+        f, err := n.Float64()
+        return f, err == nil
+    }
+    return 0, false
+}
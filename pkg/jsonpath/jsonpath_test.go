@@ -0,0 +1,198 @@
+package jsonpath_test
+
+import (
+    "bytes"
+    "encoding/json"
+    "reflect"
+    "strings"
+    "testing"
+
+    "dtctl/pkg/jsonpath"
+)
+
+const testDocument = `{
+  "kind": "ComponentList",
+  "items": [
+    {"name": "log4j-core", "version": "2.14.1", "risk": 9.5, "internal": false,
+     "project": {"name": "billing", "parent": null}, "tags": ["java", "logging"]},
+    {"name": "spring-core", "version": "5.3.8", "risk": 2,
+     "project": {"name": "billing"}, "tags": ["java"]},
+    {"name": "lodash", "version": "4.17.20", "risk": 5, "internal": true,
+     "project": {"name": "web"}, "tags": []}
+  ]
+}`
+
+func testData(t *testing.T) interface{} {
+    t.Helper()
+    var data interface{}
+    if err := json.Unmarshal([]byte(testDocument), &data); err != nil {
+        t.Fatal(err)
+    }
+    return data
+}
+
+func TestExecute(t *testing.T) {
+    tests := []struct {
+        template string
+        want     string
+    }{
+        {`{.kind}`, "ComponentList"},
+        {`{$.kind}`, "ComponentList"},
+        {`kind: {.kind}!`, "kind: ComponentList!"},
+        {`{.items[0].name}`, "log4j-core"},
+        {`{.items[-1].name}`, "lodash"},
+        {`{.items[0]['project']["name"]}`, "billing"},
+        {`{.items[*].name}`, "log4j-core spring-core lodash"},
+        {`{.items[1:].name}`, "spring-core lodash"},
+        {`{.items[:2].name}`, "log4j-core spring-core"},
+        {`{.items[-2:-1].name}`, "spring-core"},
+        {`{.items[1:10].name}`, "spring-core lodash"},
+        {`{.items[2:1].name}`, ""},
+        {`{.items[0].project.*}`, "billing "},
+        {`{.items[0].risk}`, "9.5"},
+        {`{.items[0].internal}`, "false"},
+        {`{.items[0].tags}`, `["java","logging"]`},
+        {`{.items[0].project}`, `{"name":"billing","parent":null}`},
+        {`{..project.name}`, "billing billing web"},
+        {`{.items..name}`, "log4j-core billing spring-core billing lodash web"},
+        {`{.items[?(@.name=="lodash")].version}`, "4.17.20"},
+        {`{.items[?(@.project.name!='billing')].name}`, "lodash"},
+        {`{.items[?(@.risk>=5)].name}`, "log4j-core lodash"},
+        {`{.items[?(@.risk<5)].name}`, "spring-core"},
+        {`{.items[?(@.internal)].name}`, "lodash"},
+        {`{.items[?(@.internal==false)].name}`, "log4j-core"},
+        {`{range .items[*]}{.name}{"\t"}{.project.name}{"\n"}{end}`, "log4j-core\tbilling\nspring-core\tbilling\nlodash\tweb\n"},
+        {`{range .items[?(@.risk>4)]}[{range .tags[*]}{@}{end}]{end}`, "[javalogging][]"},
+        {`{range .items[5:]}{.name}{end}`, ""},
+        {`{'{'}{.kind}{"}"}`, "{ComponentList}"},
+    }
+    data := testData(t)
+    for _, tt := range tests {
+        tmpl, err := jsonpath.Parse(tt.template)
+        if err != nil {
+            t.Errorf("Parse(%s): %v", tt.template, err)
+            continue
+        }
+        var out bytes.Buffer
+        if err := tmpl.Execute(&out, data); err != nil {
+            t.Errorf("Execute(%s): %v", tt.template, err)
+            continue
+        }
+        if out.String() != tt.want {
+            t.Errorf("Execute(%s) = %q, want %q", tt.template, out.String(), tt.want)
+        }
+    }
+}
+
+func TestExecuteMissingKeys(t *testing.T) {
+    tests := []struct {
+        template string
+        wantErr  string
+    }{
+        {`{.items[0].nonexist}`, ".items[0].nonexist: nonexist is not found"},
+        {`{.items[5]}`, ".items[5]: array index out of bounds: index 5, length 3"},
+        {`{.items[-4]}`, ".items[-4]: array index out of bounds: index -4, length 3"},
+        {`{.kind[0]}`, ".kind[0]: [0] is not found"},
+        {`{.items[0].project.parent.name}`, ".items[0].project.parent.name: name is not found"},
+        {`{range .items[*]}{.internal}{end}`, ".internal: internal is not found"},
+    }
+    data := testData(t)
+    for _, tt := range tests {
+        tmpl, err := jsonpath.Parse(tt.template)
+        if err != nil {
+            t.Errorf("Parse(%s): %v", tt.template, err)
+            continue
+        }
+        err = tmpl.Execute(&bytes.Buffer{}, data)
+        if err == nil || err.Error() != tt.wantErr {
+            t.Errorf("Execute(%s) = %v, want %s", tt.template, err, tt.wantErr)
+        }
+
+        var out bytes.Buffer
+        if err := tmpl.AllowMissingKeys(true).Execute(&out, data); err != nil {
+            t.Errorf("Execute(%s) allowing missing keys: %v", tt.template, err)
+        }
+    }
+}
+
+func TestParseErrors(t *testing.T) {
+    tests := []struct {
+        template string
+        wantErr  string
+    }{
+        {`{.items`, "unclosed {"},
+        {`{.items[0}`, "unclosed ["},
+        {`{range .items[*]}{.name}`, "{range} without {end}"},
+        {`{.name}{end}`, "unexpected {end}"},
+        {`{.items[x]}`, `invalid index "x"`},
+        {`{.items[a:]}`, `invalid slice start "a"`},
+        {`{.items[:b]}`, `invalid slice end "b"`},
+        {`{.items[?(@.risk>high)]}`, `invalid filter value "high"`},
+        {`{..}`, "expected field name after .."},
+        {`{.items.}`, `expected field name at ""`},
+        {`{'unterminated}`, "unclosed {"},
+        {`{"\q"}`, "invalid string"},
+    }
+    for _, tt := range tests {
+        _, err := jsonpath.Parse(tt.template)
+        if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+            t.Errorf("Parse(%s) = %v, want an error containing %q", tt.template, err, tt.wantErr)
+        }
+    }
+}
+
+func TestFind(t *testing.T) {
+    tests := []struct {
+        path string
+        want []interface{}
+    }{
+        {".name", []interface{}{"log4j-core"}},
+        {"{.project.name}", []interface{}{"billing"}},
+        {"name", []interface{}{"log4j-core"}},
+        {"tags[1]", []interface{}{"logging"}},
+        {".project.parent", []interface{}{nil}},
+        {".nonexist", nil},
+        {".tags[5]", nil},
+        {".project.parent.name", nil},
+    }
+    item := testData(t).(map[string]interface{})["items"].([]interface{})[0]
+    for _, tt := range tests {
+        p, err := jsonpath.Compile(tt.path)
+        if err != nil {
+            t.Errorf("Compile(%s): %v", tt.path, err)
+            continue
+        }
+        if got := p.Find(item); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("Find(%s) = %#v, want %#v", tt.path, got, tt.want)
+        }
+    }
+}
+
+func TestCompileErrors(t *testing.T) {
+    for _, path := range []string{".items[", ".a b", "[?(@.x>y)]"} {
+        if _, err := jsonpath.Compile(path); err == nil {
+            t.Errorf("Compile(%q) succeeded", path)
+        }
+    }
+}
+
+func TestFormat(t *testing.T) {
+    tests := []struct {
+        value interface{}
+        want  string
+    }{
+        {nil, ""},
+        {"text", "text"},
+        {json.Number("12"), "12"},
+        {1.5, "1.5"},
+        {1e21, "1000000000000000000000"},
+        {true, "true"},
+        {[]interface{}{"a", 1.0}, `["a",1]`},
+        {map[string]interface{}{"b": nil, "a": "x"}, `{"a":"x","b":null}`},
+    }
+    for _, tt := range tests {
+        if got := jsonpath.Format(tt.value); got != tt.want {
+            t.Errorf("Format(%#v) = %q, want %q", tt.value, got, tt.want)
+        }
+    }
+}
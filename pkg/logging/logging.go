@@ -0,0 +1,154 @@
+// Package logging writes dtctl's diagnostic log lines, either as logfmt-style
+// text for people or as one JSON object per line for log ingestion.
+package logging
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "reflect"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Log formats accepted by New.
+const (
+    FormatText = "text"
+    FormatJSON = "json"
+)
+
+// Formats lists the supported log formats.
+var Formats = []string{FormatText, FormatJSON}
+
+// Logger writes log lines to a writer. It is safe for concurrent use.
+type Logger struct {
+    out    io.Writer
+    format string
+    // now returns the timestamp of each line.
+    now func() time.Time
+
+    mu sync.Mutex
+}
+
+// New returns a Logger writing lines in format to out.
+func New(out io.Writer, format string) (*Logger, error) {
+    if format != FormatText && format != FormatJSON {
+        return nil, fmt.Errorf("unsupported log format: %s (use %s)", format, strings.Join(Formats, " or "))
+    }
+    return &Logger{out: out, format: format, now: time.Now}, nil
+}
+
+// Log writes one line with msg and fields, which alternate between keys and
+// values. Durations are written as strings like "12.5ms" and errors as their
+// message.
+func (l *Logger) Log(msg string, fields ...interface{}) {
+    ts := l.now().UTC().Format(time.RFC3339Nano)
+
+    var line []byte
+    if l.format == FormatJSON {
+        line = l.jsonLine(ts, msg, fields)
+    } else {
+        line = l.textLine(ts, msg, fields)
+    }
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.out.Write(line)
+}
+
+// textLine formats a line as key=value pairs, quoting values that need it.
+func (l *Logger) textLine(ts, msg string, fields []interface{}) []byte {
+    var b strings.Builder
+    b.WriteString("time=" + ts + " msg=" + quote(msg))
+    for i := 0; i < len(fields); i += 2 {
+        b.WriteString(" " + key(fields, i) + "=" + quote(text(value(fields, i))))
+    }
+    b.WriteByte('\n')
+    return []byte(b.String())
+}
+
+// jsonLine formats a line as a JSON object, keeping the order of fields.
+func (l *Logger) jsonLine(ts, msg string, fields []interface{}) []byte {
+    var b strings.Builder
+    b.WriteString(`{"time":` + jsonString(ts) + `,"msg":` + jsonString(msg))
+    for i := 0; i < len(fields); i += 2 {
+        data, err := marshal(jsonValue(value(fields, i)))
+        if err != nil {
+            data = jsonString(err.Error())
+        }
+        b.WriteString("," + jsonString(key(fields, i)) + ":" + data)
+    }
+    b.WriteString("}\n")
+    return []byte(b.String())
+}
+
+func key(fields []interface{}, i int) string {
+    return fmt.Sprint(fields[i])
+}
+
+func value(fields []interface{}, i int) interface{} {
+    if i+1 < len(fields) {
+        return fields[i+1]
+    }
+    return nil
+}
+
+// jsonValue converts values that do not marshal usefully on their own.
+func jsonValue(v interface{}) interface{} {
+    switch v := v.(type) {
+    case time.Duration:
+        return v.String()
+    case error:
+        return v.Error()
+    }
+    return v
+}
+
+// text formats a value for a text line.
+func text(v interface{}) string {
+    switch v := v.(type) {
+    case string:
+        return v
+    case error:
+        return v.Error()
+    case nil:
+        return ""
+    case fmt.Stringer:
+        return v.String()
+    }
+    // Maps and slices, such as HTTP headers, read better as JSON.
+    switch reflect.ValueOf(v).Kind() {
+    case reflect.Map, reflect.Slice:
+        if data, err := marshal(v); err == nil {
+            return data
+        }
+    }
+    return fmt.Sprint(v)
+}
+
+// quote quotes s when it is empty or contains spaces, quotes or equal signs.
+func quote(s string) string {
+    if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+        return strconv.Quote(s)
+    }
+    return s
+}
+
+func jsonString(s string) string {
+    data, _ := marshal(s)
+    return data
+}
+
+// marshal encodes v as JSON without escaping &, < and >, which are common in
+// URLs and bodies and harder to read escaped.
+func marshal(v interface{}) (string, error) {
+    var b strings.Builder
+    enc := json.NewEncoder(&b)
+    enc.SetEscapeHTML(false)
+    if err := enc.Encode(v); err != nil {
+        return "", err
+    }
+    return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+    "bytes"
+    "errors"
+    "testing"
+    "time"
+)
+
+func TestLog(t *testing.T) {
+    tests := []struct {
+        format string
+        want   string
+    }{
+        {FormatText, `time=2026-01-02T03:04:05Z msg="HTTP request" url="http://dt/api?a=1&b=2" status=200 duration=1.5ms error=boom header="{\"X-Api-Key\":[\"REDACTED\"]}"` + "\n"},
+        {FormatJSON, `{"time":"2026-01-02T03:04:05Z","msg":"HTTP request","url":"http://dt/api?a=1&b=2","status":200,"duration":"1.5ms","error":"boom","header":{"X-Api-Key":["REDACTED"]}}` + "\n"},
+    }
+    for _, tt := range tests {
+        var out bytes.Buffer
+        l, err := New(&out, tt.format)
+        if err != nil {
+            t.Fatal(err)
+        }
+        l.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+        l.Log("HTTP request",
+            "url", "http://dt/api?a=1&b=2",
+            "status", 200,
+            "duration", 1500*time.Microsecond,
+            "error", errors.New("boom"),
+            "header", map[string][]string{"X-Api-Key": {"REDACTED"}})
+        if got := out.String(); got != tt.want {
+            t.Errorf("%s:\ngot  %s\nwant %s", tt.format, got, tt.want)
+        }
+    }
+}
+
+func TestNewRejectsUnknownFormat(t *testing.T) {
+    if _, err := New(&bytes.Buffer{}, "xml"); err == nil {
+        t.Error("expected an error for an unknown format")
+    }
+}
@@ -0,0 +1,295 @@
+package policy
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "dtctl/pkg/dependencytrack"
+)
+
+// subjects maps the condition subjects that can be evaluated to their
+// evaluators.
+var subjects = map[string]conditionFunc{
+    dependencytrack.SubjectAge:             age,
+    dependencytrack.SubjectComponentHash:   componentHash,
+    dependencytrack.SubjectCoordinates:     coordinates,
+    dependencytrack.SubjectCPE:             cpe,
+    dependencytrack.SubjectCWE:             cwe,
+    dependencytrack.SubjectEPSS:            epss,
+    dependencytrack.SubjectLicense:         license,
+    dependencytrack.SubjectLicenseGroup:    licenseGroup,
+    dependencytrack.SubjectPackageURL:      packageURL,
+    dependencytrack.SubjectSeverity:        severity,
+    dependencytrack.SubjectSWIDTagID:       swidTagID,
+    dependencytrack.SubjectVersion:         version,
+    dependencytrack.SubjectVulnerabilityID: vulnerabilityID,
+}
+
+// now is replaced in tests.
+var now = time.Now
+
+// ResolveLicenseGroups fetches the license groups p's LICENSE_GROUP
+// conditions refer to and attaches them to the conditions, so the conditions
+// can be evaluated.
+func ResolveLicenseGroups(ctx context.Context, client dependencytrack.API, p *dependencytrack.Policy) error {
+    groups := map[string]*dependencytrack.LicenseGroup{}
+    for i := range p.PolicyConditions {
+        c := &p.PolicyConditions[i]
+        if c.Subject != dependencytrack.SubjectLicenseGroup || c.LicenseGroup != nil {
+            continue
+        }
+        g, ok := groups[c.Value]
+        if !ok {
+            var err error
+            if g, err = client.GetLicenseGroupContext(ctx, c.Value); err != nil {
+                return fmt.Errorf("license group %s: %w", c.Value, err)
+            }
+            groups[c.Value] = g
+        }
+        c.LicenseGroup = g
+    }
+    return nil
+}
+
+// componentHash matches a COMPONENT_HASH condition against the component's
+// hash of the condition's algorithm. IS matches a component with the
+// condition's hash, IS_NOT one without it. A component without a hash of
+// that algorithm cannot be evaluated.
+func componentHash(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    if c.Hash == nil {
+        return false, fmt.Errorf("value %q is not a hash", c.Value)
+    }
+    actual, ok := t.Component.Hash(c.Hash.Algorithm)
+    if !ok {
+        return false, fmt.Errorf("hash algorithm %s is not supported", c.Hash.Algorithm)
+    }
+    if strings.TrimSpace(actual) == "" {
+        return false, fmt.Errorf("component %s has no %s hash", t.Component.Name, dependencytrack.CanonicalHashAlgorithm(c.Hash.Algorithm))
+    }
+    equal := strings.EqualFold(strings.TrimSpace(actual), strings.TrimSpace(c.Hash.Value))
+    return is(c.Operator, equal)
+}
+
+// packageURL, cpe and swidTagID match the component's package URL, CPE and
+// SWID tag ID against the condition's regular expression.
+func packageURL(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    return matches(c.Operator, c.Value, t.Component.PURL)
+}
+
+func cpe(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    return matches(c.Operator, c.Value, t.Component.CPE)
+}
+
+func swidTagID(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    return matches(c.Operator, c.Value, t.Component.SwidTagID)
+}
+
+// coordinatesValue is the value of a COORDINATES condition.
+type coordinatesValue struct {
+    Group   string `json:"group"`
+    Name    string `json:"name"`
+    Version string `json:"version"`
+}
+
+// versionComparators are the prefixes that turn the version of a
+// COORDINATES condition into a comparison, e.g. ">=2.0", longest first.
+var versionComparators = []struct {
+    prefix   string
+    operator string
+}{
+    {"==", dependencytrack.OperatorNumericEqual},
+    {"!=", dependencytrack.OperatorNumericNotEqual},
+    {">=", dependencytrack.OperatorNumericGreaterThanOrEqual},
+    {"<=", dependencytrack.OperatorNumericLessThanOrEqual},
+    {">", dependencytrack.OperatorNumericGreaterThan},
+    {"<", dependencytrack.OperatorNumericLessThan},
+}
+
+// coordinates matches a COORDINATES condition, whose value holds a group,
+// name and version. Group and name are regular expressions; the version is
+// one too, unless it starts with a comparison such as ">=2.0". An empty
+// part or "*" matches anything. MATCHES matches a component that matches
+// every part, NO_MATCH one that does not.
+func coordinates(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    var want coordinatesValue
+    if err := json.Unmarshal([]byte(c.Value), &want); err != nil {
+        return false, fmt.Errorf("value %q is not a group, name and version", c.Value)
+    }
+    if c.Operator != dependencytrack.OperatorMatches && c.Operator != dependencytrack.OperatorNoMatch {
+        return false, fmt.Errorf("operator %s is not supported", c.Operator)
+    }
+
+    all := true
+    for _, part := range []struct{ pattern, actual string }{
+        {want.Group, t.Component.Group},
+        {want.Name, t.Component.Name},
+    } {
+        if part.pattern == "" || part.pattern == "*" {
+            continue
+        }
+        ok, err := matches(dependencytrack.OperatorMatches, part.pattern, part.actual)
+        if err != nil {
+            return false, err
+        }
+        all = all && ok
+    }
+    if v := strings.TrimSpace(want.Version); v != "" && v != "*" {
+        ok, err := coordinatesVersion(v, t.Component)
+        if err != nil {
+            return false, err
+        }
+        all = all && ok
+    }
+
+    if c.Operator == dependencytrack.OperatorNoMatch {
+        return !all, nil
+    }
+    return all, nil
+}
+
+func coordinatesVersion(pattern string, comp dependencytrack.Component) (bool, error) {
+    for _, cmp := range versionComparators {
+        if strings.HasPrefix(pattern, cmp.prefix) {
+            return compareVersion(cmp.operator, comp, strings.TrimSpace(strings.TrimPrefix(pattern, cmp.prefix)))
+        }
+    }
+    return matches(dependencytrack.OperatorMatches, pattern, comp.Version)
+}
+
+// version compares the component's version with the condition's using the
+// NUMERIC_* operators, by the rules of the component's ecosystem; see
+// CompareVersions.
+func version(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    return compareVersion(c.Operator, t.Component, c.Value)
+}
+
+func compareVersion(operator string, comp dependencytrack.Component, want string) (bool, error) {
+    if strings.TrimSpace(comp.Version) == "" {
+        return false, fmt.Errorf("component %s has no version", comp.Name)
+    }
+    cmp, err := CompareVersions(Ecosystem(comp.PURL), comp.Version, want)
+    if err != nil {
+        return false, err
+    }
+    return compare(operator, cmp)
+}
+
+// unresolvedLicense is the value of LICENSE conditions on components whose
+// license Dependency-Track did not recognize.
+const unresolvedLicense = "unresolved"
+
+// license matches a LICENSE condition, whose value is the UUID of a license
+// or "unresolved". The SPDX ID of the license is accepted too. A component
+// with an unresolved license matches no license by UUID, not even with
+// IS_NOT.
+func license(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    resolved := t.Component.ResolvedLicense
+    if strings.EqualFold(c.Value, unresolvedLicense) {
+        return is(c.Operator, resolved == nil)
+    }
+    if resolved == nil {
+        _, err := is(c.Operator, false)
+        return false, err
+    }
+    return is(c.Operator, resolved.UUID == c.Value || strings.EqualFold(resolved.LicenseID, c.Value))
+}
+
+// licenseGroup matches a LICENSE_GROUP condition, whose value is the UUID
+// of a license group that must have been fetched with ResolveLicenseGroups.
+// A component with an unresolved license is in no group.
+func licenseGroup(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    if c.LicenseGroup == nil {
+        return false, fmt.Errorf("license group %s was not fetched", c.Value)
+    }
+    resolved := t.Component.ResolvedLicense
+    return is(c.Operator, resolved != nil && c.LicenseGroup.Contains(*resolved))
+}
+
+var periodPattern = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?$`)
+
+// age compares the time since the component's version was published with
+// the condition's ISO 8601 period, e.g. P1Y6M, in days. A component without
+// a publish date cannot be evaluated.
+func age(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    m := periodPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(c.Value)))
+    if m == nil || strings.Join(m[1:], "") == "" {
+        return false, fmt.Errorf("value %q is not an ISO 8601 period such as P1Y", c.Value)
+    }
+    var n [4]int
+    for i := range n {
+        n[i], _ = strconv.Atoi(m[i+1])
+    }
+    published, ok := t.Component.PublishedAt()
+    if !ok {
+        return false, fmt.Errorf("component %s has no publish date", t.Component.Name)
+    }
+
+    day := func(t time.Time) time.Time {
+        y, m, d := t.UTC().Date()
+        return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+    }
+    limit := day(published).AddDate(n[0], n[1], 7*n[2]+n[3])
+    today := day(now())
+    cmp := 0
+    switch {
+    case today.After(limit):
+        cmp = 1
+    case today.Before(limit):
+        cmp = -1
+    }
+    return compare(c.Operator, cmp)
+}
+
+// is applies IS or IS_NOT to whether the component has the condition's
+// value.
+func is(operator string, equal bool) (bool, error) {
+    switch operator {
+    case dependencytrack.OperatorIs:
+        return equal, nil
+    case dependencytrack.OperatorIsNot:
+        return !equal, nil
+    }
+    return false, fmt.Errorf("operator %s is not supported", operator)
+}
+
+// matches applies MATCHES or NO_MATCH with pattern, a regular expression
+// that must match all of actual. A component without the value matches
+// neither.
+func matches(operator, pattern, actual string) (bool, error) {
+    if operator != dependencytrack.OperatorMatches && operator != dependencytrack.OperatorNoMatch {
+        return false, fmt.Errorf("operator %s is not supported", operator)
+    }
+    re, err := regexp.Compile("^(?:" + pattern + ")$")
+    if err != nil {
+        return false, fmt.Errorf("invalid regular expression %q: %v", pattern, err)
+    }
+    if actual == "" {
+        return false, nil
+    }
+    return re.MatchString(actual) == (operator == dependencytrack.OperatorMatches), nil
+}
+
+// compare applies a NUMERIC_* operator to cmp, which is -1, 0 or 1 as the
+// component's value is less than, equal to or greater than the condition's.
+func compare(operator string, cmp int) (bool, error) {
+    switch operator {
+    case dependencytrack.OperatorNumericGreaterThan:
+        return cmp > 0, nil
+    case dependencytrack.OperatorNumericGreaterThanOrEqual:
+        return cmp >= 0, nil
+    case dependencytrack.OperatorNumericEqual:
+        return cmp == 0, nil
+    case dependencytrack.OperatorNumericNotEqual:
+        return cmp != 0, nil
+    case dependencytrack.OperatorNumericLessThanOrEqual:
+        return cmp <= 0, nil
+    case dependencytrack.OperatorNumericLessThan:
+        return cmp < 0, nil
+    }
+    return false, fmt.Errorf("operator %s is not supported", operator)
+}
@@ -0,0 +1,235 @@
+package policy
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "dtctl/pkg/dependencytrack"
+)
+
+var (
+    apache = dependencytrack.License{UUID: "55555555-0000-0000-0000-000000000001", LicenseID: "Apache-2.0"}
+    gpl    = dependencytrack.License{UUID: "55555555-0000-0000-0000-000000000002", LicenseID: "GPL-3.0-only"}
+
+    log4j = dependencytrack.Component{
+        Group:           "org.apache.logging.log4j",
+        Name:            "log4j-core",
+        Version:         "2.17.1",
+        PURL:            "pkg:maven/org.apache.logging.log4j/log4j-core@2.17.1",
+        CPE:             "cpe:2.3:a:apache:log4j:2.17.1:*:*:*:*:*:*:*",
+        SwidTagID:       "swid:apache.org/log4j-core-2.17.1",
+        ResolvedLicense: &apache,
+        // 2021-12-28, when 2.17.1 was released.
+        RepositoryMeta: &dependencytrack.RepositoryMeta{Published: 1640649600000},
+    }
+    // bare is a component Dependency-Track knows little about.
+    bare = dependencytrack.Component{Name: "vendored", License: "Proprietary"}
+)
+
+// conditionTest is a case of a table-driven test of a condition evaluator.
+type conditionTest struct {
+    operator, value string
+    component       dependencytrack.Component
+    want            bool
+    // wantErr means the condition cannot be evaluated.
+    wantErr bool
+}
+
+func runConditionTests(t *testing.T, subject string, tests []conditionTest) {
+    t.Helper()
+    for _, tt := range tests {
+        c := dependencytrack.PolicyCondition{Operator: tt.operator, Subject: subject, Value: tt.value}
+        r := evaluateCondition(c, Target{Component: tt.component})
+        switch {
+        case tt.wantErr && r.Err == nil:
+            t.Errorf("%s on %s: matched = %v, want an error", Describe(c), tt.component.Name, r.Matched)
+        case !tt.wantErr && r.Err != nil:
+            t.Errorf("%s on %s failed: %v", Describe(c), tt.component.Name, r.Err)
+        case r.Matched != tt.want:
+            t.Errorf("%s on %s: matched = %v, want %v", Describe(c), tt.component.Name, r.Matched, tt.want)
+        }
+    }
+}
+
+func TestPackageURL(t *testing.T) {
+    runConditionTests(t, dependencytrack.SubjectPackageURL, []conditionTest{
+        {"MATCHES", `pkg:maven/org\.apache\.logging\.log4j/.*`, log4j, true, false},
+        {"MATCHES", "pkg:npm/.*", log4j, false, false},
+        // The whole package URL must match.
+        {"MATCHES", "log4j-core", log4j, false, false},
+        {"NO_MATCH", "pkg:npm/.*", log4j, true, false},
+        {"NO_MATCH", "pkg:maven/.*", log4j, false, false},
+        {"MATCHES", ".*", bare, false, false},
+        {"NO_MATCH", "pkg:maven/.*", bare, false, false},
+        {"MATCHES", "pkg:maven/(", log4j, false, true},
+        {"IS", "pkg:maven/org.apache.logging.log4j/log4j-core@2.17.1", log4j, false, true},
+    })
+}
+
+func TestCPE(t *testing.T) {
+    runConditionTests(t, dependencytrack.SubjectCPE, []conditionTest{
+        {"MATCHES", `cpe:2\.3:a:apache:log4j:.*`, log4j, true, false},
+        {"NO_MATCH", `cpe:2\.3:a:apache:log4j:.*`, log4j, false, false},
+        {"NO_MATCH", `cpe:2\.3:a:openssl:.*`, log4j, true, false},
+        {"MATCHES", ".*", bare, false, false},
+        {"IS_NOT", "cpe:2.3:a:apache:log4j", log4j, false, true},
+    })
+}
+
+func TestSWIDTagID(t *testing.T) {
+    runConditionTests(t, dependencytrack.SubjectSWIDTagID, []conditionTest{
+        {"MATCHES", "swid:apache.org/.*", log4j, true, false},
+        {"NO_MATCH", "swid:apache.org/.*", log4j, false, false},
+        {"MATCHES", ".*", bare, false, false},
+        {"MATCHES", "[", log4j, false, true},
+    })
+}
+
+func TestCoordinates(t *testing.T) {
+    runConditionTests(t, dependencytrack.SubjectCoordinates, []conditionTest{
+        {"MATCHES", `{"group":"org.apache.logging.log4j","name":"log4j-core","version":"2.17.1"}`, log4j, true, false},
+        {"MATCHES", `{"group":"org.apache.*","name":"*","version":""}`, log4j, true, false},
+        {"MATCHES", `{"group":"","name":"log4j-.*"}`, log4j, true, false},
+        {"MATCHES", `{"group":"org.apache.logging.log4j","name":"log4j-api"}`, log4j, false, false},
+        {"MATCHES", `{"name":"log4j-core","version":"<2.17.0"}`, log4j, false, false},
+        {"MATCHES", `{"name":"log4j-core","version":">=2.17.0"}`, log4j, true, false},
+        {"MATCHES", `{"name":"log4j-core","version":"!=2.17.1"}`, log4j, false, false},
+        {"MATCHES", `{"name":"log4j-core","version":"2\\.17\\..*"}`, log4j, true, false},
+        {"NO_MATCH", `{"group":"org.apache.logging.log4j","name":"log4j-api"}`, log4j, true, false},
+        {"NO_MATCH", `{"name":"log4j-core","version":"<=2.17.1"}`, log4j, false, false},
+        {"MATCHES", `{"group":"org.apache.*"}`, bare, false, false},
+        {"MATCHES", `{"name":"vendored","version":">1.0"}`, bare, false, true},
+        {"MATCHES", "log4j-core", log4j, false, true},
+        {"IS", `{"name":"log4j-core"}`, log4j, false, true},
+    })
+}
+
+func TestVersion(t *testing.T) {
+    npm := dependencytrack.Component{Name: "express", Version: "5.0.0-beta.1", PURL: "pkg:npm/express@5.0.0-beta.1"}
+    pypi := dependencytrack.Component{Name: "django", Version: "4.2rc1", PURL: "pkg:pypi/django@4.2rc1"}
+    runConditionTests(t, dependencytrack.SubjectVersion, []conditionTest{
+        {"NUMERIC_LESS_THAN", "2.17.2", log4j, true, false},
+        {"NUMERIC_LESS_THAN", "2.17.1", log4j, false, false},
+        {"NUMERIC_LESSER_THAN_OR_EQUAL", "2.17.1", log4j, true, false},
+        {"NUMERIC_EQUAL", "2.17.1.0", log4j, true, false},
+        {"NUMERIC_NOT_EQUAL", "2.17.1", log4j, false, false},
+        {"NUMERIC_GREATER_THAN", "2.9.0", log4j, true, false},
+        {"NUMERIC_GREATER_THAN_OR_EQUAL", "2.17.1-SNAPSHOT", log4j, true, false},
+        // A pre-release comes before the release.
+        {"NUMERIC_LESS_THAN", "5.0.0", npm, true, false},
+        {"NUMERIC_GREATER_THAN", "4.18.2", npm, true, false},
+        {"NUMERIC_LESS_THAN", "4.2", pypi, true, false},
+        {"NUMERIC_GREATER_THAN", "4.2b1", pypi, true, false},
+        {"NUMERIC_LESS_THAN", "1.0", bare, false, true},
+        {"NUMERIC_LESS_THAN", "not.a.version", npm, false, true},
+        {"IS", "2.17.1", log4j, false, true},
+    })
+}
+
+func TestLicense(t *testing.T) {
+    runConditionTests(t, dependencytrack.SubjectLicense, []conditionTest{
+        {"IS", apache.UUID, log4j, true, false},
+        {"IS", "apache-2.0", log4j, true, false},
+        {"IS", gpl.UUID, log4j, false, false},
+        {"IS_NOT", gpl.UUID, log4j, true, false},
+        {"IS_NOT", apache.UUID, log4j, false, false},
+        {"IS", "unresolved", log4j, false, false},
+        {"IS", "unresolved", bare, true, false},
+        {"IS_NOT", "unresolved", log4j, true, false},
+        // An unresolved license is neither GPL nor not GPL.
+        {"IS", gpl.UUID, bare, false, false},
+        {"IS_NOT", gpl.UUID, bare, false, false},
+        {"MATCHES", "Apache.*", log4j, false, true},
+    })
+}
+
+func TestLicenseGroup(t *testing.T) {
+    permissive := &dependencytrack.LicenseGroup{UUID: "66666666-0000-0000-0000-000000000001", Name: "Permissive", Licenses: []dependencytrack.License{apache}}
+    tests := []struct {
+        operator  string
+        group     *dependencytrack.LicenseGroup
+        component dependencytrack.Component
+        want      bool
+        wantErr   bool
+    }{
+        {"IS", permissive, log4j, true, false},
+        {"IS_NOT", permissive, log4j, false, false},
+        {"IS", permissive, bare, false, false},
+        {"IS_NOT", permissive, bare, true, false},
+        {"IS", nil, log4j, false, true},
+        {"MATCHES", permissive, log4j, false, true},
+    }
+    for _, tt := range tests {
+        c := dependencytrack.PolicyCondition{Operator: tt.operator, Subject: dependencytrack.SubjectLicenseGroup, Value: permissive.UUID, LicenseGroup: tt.group}
+        r := evaluateCondition(c, Target{Component: tt.component})
+        if (r.Err != nil) != tt.wantErr || r.Matched != tt.want {
+            t.Errorf("%s on %s (group fetched: %v) = %v, %v, want %v, error %v", Describe(c), tt.component.Name, tt.group != nil, r.Matched, r.Err, tt.want, tt.wantErr)
+        }
+    }
+}
+
+// fakeAPI serves license groups and counts the requests for them.
+type fakeAPI struct {
+    dependencytrack.API
+    groups   map[string]*dependencytrack.LicenseGroup
+    requests int
+}
+
+func (f *fakeAPI) GetLicenseGroupContext(ctx context.Context, uuid string) (*dependencytrack.LicenseGroup, error) {
+    f.requests++
+    if g, ok := f.groups[uuid]; ok {
+        return g, nil
+    }
+    return nil, errors.New("not found")
+}
+
+func TestResolveLicenseGroups(t *testing.T) {
+    permissive := &dependencytrack.LicenseGroup{UUID: "permissive", Licenses: []dependencytrack.License{apache}}
+    api := &fakeAPI{groups: map[string]*dependencytrack.LicenseGroup{"permissive": permissive}}
+    p := dependencytrack.Policy{PolicyConditions: []dependencytrack.PolicyCondition{
+        {Operator: "IS", Subject: dependencytrack.SubjectLicenseGroup, Value: "permissive"},
+        {Operator: "IS", Subject: dependencytrack.SubjectLicense, Value: "unresolved"},
+        {Operator: "IS_NOT", Subject: dependencytrack.SubjectLicenseGroup, Value: "permissive"},
+    }}
+    if err := ResolveLicenseGroups(context.Background(), api, &p); err != nil {
+        t.Fatal(err)
+    }
+    if p.PolicyConditions[0].LicenseGroup != permissive || p.PolicyConditions[2].LicenseGroup != permissive {
+        t.Errorf("license groups not attached: %+v", p.PolicyConditions)
+    }
+    if p.PolicyConditions[1].LicenseGroup != nil {
+        t.Errorf("license group attached to a LICENSE condition")
+    }
+    if api.requests != 1 {
+        t.Errorf("%d requests for license groups, want 1", api.requests)
+    }
+
+    p.PolicyConditions = append(p.PolicyConditions, dependencytrack.PolicyCondition{Operator: "IS", Subject: dependencytrack.SubjectLicenseGroup, Value: "missing"})
+    if err := ResolveLicenseGroups(context.Background(), api, &p); err == nil {
+        t.Errorf("ResolveLicenseGroups succeeded for a missing group")
+    }
+}
+
+func TestAge(t *testing.T) {
+    defer func(orig func() time.Time) { now = orig }(now)
+    // One year, one day after log4j was published.
+    now = func() time.Time { return time.Date(2022, 12, 29, 15, 4, 5, 0, time.UTC) }
+
+    runConditionTests(t, dependencytrack.SubjectAge, []conditionTest{
+        {"NUMERIC_GREATER_THAN", "P1Y", log4j, true, false},
+        {"NUMERIC_GREATER_THAN", "P1Y1D", log4j, false, false},
+        {"NUMERIC_EQUAL", "P1Y1D", log4j, true, false},
+        {"NUMERIC_GREATER_THAN_OR_EQUAL", "P1Y1D", log4j, true, false},
+        {"NUMERIC_LESS_THAN", "P2Y", log4j, true, false},
+        {"NUMERIC_LESSER_THAN_OR_EQUAL", "P12M", log4j, false, false},
+        {"NUMERIC_NOT_EQUAL", "P366D", log4j, false, false},
+        {"NUMERIC_GREATER_THAN", "P52W", log4j, true, false},
+        {"NUMERIC_GREATER_THAN", "p6m", log4j, true, false},
+        {"NUMERIC_GREATER_THAN", "P1Y", bare, false, true},
+        {"NUMERIC_GREATER_THAN", "P", log4j, false, true},
+        {"NUMERIC_GREATER_THAN", "1 year", log4j, false, true},
+        {"IS", "P1Y", log4j, false, true},
+    })
+}
@@ -0,0 +1,153 @@
+// Package policy evaluates Dependency-Track policies against components the
+// way the server does: every condition of a policy is evaluated, and the
+// policy's ANY or ALL operator decides whether the matching conditions add up
+// to a violation. Scopes, subjects and their edge cases follow the server's
+// rules unless noted.
+package policy
+
+import (
+    "fmt"
+    "strings"
+
+    "dtctl/pkg/dependencytrack"
+)
+
+// Policy operators, which combine the results of a policy's conditions.
+const (
+    OperatorAny = "ANY"
+    OperatorAll = "ALL"
+)
+
+// State is the outcome of evaluating a policy against a component.
+type State string
+
+const (
+    Violated    State = "VIOLATED"
+    NotViolated State = "NOT VIOLATED"
+    // Unknown means the outcome depends on a condition that could not be
+    // evaluated, e.g. because of an unsupported subject.
+    Unknown State = "UNKNOWN"
+)
+
+// Target is what a policy is evaluated against.
+type Target struct {
+    Component dependencytrack.Component
+    // Findings are the vulnerabilities of the component. Conditions on
+    // vulnerabilities can only be evaluated when FindingsLoaded is set,
+    // which tells that Findings is complete, even if empty.
+    Findings       []dependencytrack.Finding
+    FindingsLoaded bool
+}
+
+// ConditionResult is the outcome of a single condition. A condition matches
+// when it describes the target, e.g. a COMPONENT_HASH IS condition whose hash
+// equals the component's; matching conditions are what make up a violation.
+type ConditionResult struct {
+    Condition dependencytrack.PolicyCondition
+    Matched   bool
+    // Err is set when the condition could not be evaluated. Matched is
+    // false then.
+    Err error
+}
+
+// Result is the outcome of a policy for one target.
+type Result struct {
+    Policy     dependencytrack.Policy
+    Target     Target
+    State      State
+    Conditions []ConditionResult
+}
+
+// Matched returns the conditions that matched the target.
+func (r Result) Matched() []dependencytrack.PolicyCondition {
+    var matched []dependencytrack.PolicyCondition
+    for _, c := range r.Conditions {
+        if c.Matched {
+            matched = append(matched, c.Condition)
+        }
+    }
+    return matched
+}
+
+// Errors returns the reasons conditions could not be evaluated.
+func (r Result) Errors() []error {
+    var errs []error
+    for _, c := range r.Conditions {
+        if c.Err != nil {
+            errs = append(errs, c.Err)
+        }
+    }
+    return errs
+}
+
+// Evaluate evaluates every condition of p against t and combines them with
+// p's operator: with ANY one matching condition is a violation, with ALL
+// every condition must match. A policy without an operator is treated as
+// ANY, and a policy without conditions is never violated.
+//
+// Conditions that cannot be evaluated make the result Unknown, unless the
+// other conditions settle it: a match under ANY or a mismatch under ALL.
+func Evaluate(p dependencytrack.Policy, t Target) Result {
+    r := Result{Policy: p, Target: t}
+    matched, unknown := 0, 0
+    for _, c := range p.PolicyConditions {
+        cr := evaluateCondition(c, t)
+        r.Conditions = append(r.Conditions, cr)
+        switch {
+        case cr.Err != nil:
+            unknown++
+        case cr.Matched:
+            matched++
+        }
+    }
+    total := len(p.PolicyConditions)
+
+    switch {
+    case total == 0:
+        r.State = NotViolated
+    case strings.EqualFold(p.Operator, OperatorAll):
+        switch {
+        case matched == total:
+            r.State = Violated
+        case matched+unknown == total:
+            r.State = Unknown
+        default:
+            r.State = NotViolated
+        }
+    default:
+        switch {
+        case matched > 0:
+            r.State = Violated
+        case unknown > 0:
+            r.State = Unknown
+        default:
+            r.State = NotViolated
+        }
+    }
+    return r
+}
+
+// conditionFunc decides whether a condition matches a target.
+type conditionFunc func(c dependencytrack.PolicyCondition, t Target) (bool, error)
+
+func evaluateCondition(c dependencytrack.PolicyCondition, t Target) ConditionResult {
+    eval, ok := subjects[c.Subject]
+    if !ok {
+        return ConditionResult{Condition: c, Err: fmt.Errorf("condition %s cannot be evaluated: subject %s is not supported", Describe(c), c.Subject)}
+    }
+    matched, err := eval(c, t)
+    if err != nil {
+        return ConditionResult{Condition: c, Err: fmt.Errorf("condition %s cannot be evaluated: %v", Describe(c), err)}
+    }
+    return ConditionResult{Condition: c, Matched: matched}
+}
+
+// Describe returns a short description of a condition, e.g.
+// "COMPONENT_HASH IS SHA-256:aaaa...".
+func Describe(c dependencytrack.PolicyCondition) string {
+    value := c.Value
+    if c.Hash != nil {
+        value = c.Hash.Algorithm + ":" + c.Hash.Value
+    }
+    return c.Subject + " " + c.Operator + " " + value
+}
@@ -0,0 +1,114 @@
+package policy
+
+import (
+    "strings"
+    "testing"
+
+    "dtctl/pkg/dependencytrack"
+)
+
+const (
+    sumA = "aaaa000000000000000000000000000000000000000000000000000000000001"
+    sumB = "bbbb000000000000000000000000000000000000000000000000000000000002"
+)
+
+func hash(operator, sha256 string) dependencytrack.PolicyCondition {
+    h := &dependencytrack.HashValue{Algorithm: "SHA-256", Value: sha256}
+    return dependencytrack.PolicyCondition{UUID: operator + sha256[:4], Operator: operator, Subject: dependencytrack.SubjectComponentHash, Value: h.Encode(), Hash: h}
+}
+
+var unsupported = dependencytrack.PolicyCondition{UUID: "distance", Operator: "NUMERIC_GREATER_THAN", Subject: "VERSION_DISTANCE", Value: `{"major":"1"}`}
+
+func TestEvaluate(t *testing.T) {
+    component := dependencytrack.Component{Name: "log4j-core", Sha256: strings.ToUpper(sumA)}
+
+    tests := []struct {
+        name       string
+        operator   string
+        conditions []dependencytrack.PolicyCondition
+        want       State
+        matched    int
+    }{
+        {"no conditions", OperatorAny, nil, NotViolated, 0},
+        {"any, one of two match", OperatorAny, []dependencytrack.PolicyCondition{hash("IS", sumB), hash("IS", sumA)}, Violated, 1},
+        {"any, none match", OperatorAny, []dependencytrack.PolicyCondition{hash("IS", sumB), hash("IS_NOT", sumA)}, NotViolated, 0},
+        {"empty operator is any", "", []dependencytrack.PolicyCondition{hash("IS", sumB), hash("IS", sumA)}, Violated, 1},
+        {"all, every condition matches", OperatorAll, []dependencytrack.PolicyCondition{hash("IS", sumA), hash("IS_NOT", sumB)}, Violated, 2},
+        {"all, one of two match", OperatorAll, []dependencytrack.PolicyCondition{hash("IS", sumA), hash("IS", sumB)}, NotViolated, 1},
+        {"all is case-insensitive", "all", []dependencytrack.PolicyCondition{hash("IS", sumA), hash("IS", sumB)}, NotViolated, 1},
+        {"any, match settles unknown", OperatorAny, []dependencytrack.PolicyCondition{unsupported, hash("IS", sumA)}, Violated, 1},
+        {"any, unknown without match", OperatorAny, []dependencytrack.PolicyCondition{unsupported, hash("IS", sumB)}, Unknown, 0},
+        {"all, mismatch settles unknown", OperatorAll, []dependencytrack.PolicyCondition{unsupported, hash("IS", sumB)}, NotViolated, 0},
+        {"all, unknown with matches", OperatorAll, []dependencytrack.PolicyCondition{unsupported, hash("IS", sumA)}, Unknown, 1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            p := dependencytrack.Policy{Name: "p", Operator: tt.operator, PolicyConditions: tt.conditions}
+            r := Evaluate(p, Target{Component: component})
+            if r.State != tt.want {
+                t.Errorf("State = %s, want %s", r.State, tt.want)
+            }
+            if got := len(r.Matched()); got != tt.matched {
+                t.Errorf("%d conditions matched, want %d", got, tt.matched)
+            }
+            if len(r.Conditions) != len(tt.conditions) {
+                t.Errorf("%d condition results, want %d", len(r.Conditions), len(tt.conditions))
+            }
+        })
+    }
+}
+
+func TestEvaluateConditionErrors(t *testing.T) {
+    md4 := &dependencytrack.HashValue{Algorithm: "MD4", Value: "31d6cfe0d16ae931b73c59d7e0c089c0"}
+    for _, c := range []dependencytrack.PolicyCondition{
+        unsupported,
+        {Operator: "IS", Subject: dependencytrack.SubjectComponentHash, Value: "not json"},
+        {Operator: "IS", Subject: dependencytrack.SubjectComponentHash, Value: md4.Encode(), Hash: md4},
+        {Operator: "MATCHES", Subject: dependencytrack.SubjectComponentHash, Value: hash("IS", sumA).Value, Hash: hash("IS", sumA).Hash},
+    } {
+        r := evaluateCondition(c, Target{})
+        if r.Err == nil || r.Matched {
+            t.Errorf("evaluateCondition(%s) = %+v, want an error", Describe(c), r)
+        }
+    }
+}
+
+func TestEvaluateHashAlgorithms(t *testing.T) {
+    component := dependencytrack.Component{
+        Name:       "openssl",
+        Md5:        "d41d8cd98f00b204e9800998ecf8427e",
+        Sha1:       "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+        Sha512:     "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e",
+        Sha3256:    "a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a",
+        Blake2b256: "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8",
+        Blake3:     "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262",
+    }
+
+    tests := []struct {
+        algorithm, value, operator string
+        want                       State
+    }{
+        {"MD5", component.Md5, "IS", Violated},
+        {"SHA-1", component.Sha1, "IS", Violated},
+        {"SHA-1", component.Md5, "IS", NotViolated},
+        {"SHA-512", strings.ToUpper(component.Sha512), "IS", Violated},
+        {"SHA3-256", component.Sha3256, "IS_NOT", NotViolated},
+        {"sha3_256", component.Sha3256, "IS", Violated},
+        {"BLAKE2b-256", component.Blake2b256, "IS", Violated},
+        {"BLAKE3", component.Blake3, "IS_NOT", NotViolated},
+        // The SHA-256 of the component is unknown, so neither IS nor IS_NOT
+        // can be decided.
+        {"SHA-256", sumA, "IS", Unknown},
+        {"SHA-256", sumA, "IS_NOT", Unknown},
+        {"SHA-384", sumA, "IS_NOT", Unknown},
+        {"BLAKE2b-512", sumA, "IS", Unknown},
+    }
+    for _, tt := range tests {
+        h := &dependencytrack.HashValue{Algorithm: tt.algorithm, Value: tt.value}
+        c := dependencytrack.PolicyCondition{Operator: tt.operator, Subject: dependencytrack.SubjectComponentHash, Value: h.Encode(), Hash: h}
+        r := Evaluate(dependencytrack.Policy{PolicyConditions: []dependencytrack.PolicyCondition{c}}, Target{Component: component})
+        if r.State != tt.want {
+            t.Errorf("%s %s %s: State = %s, want %s (%v)", tt.algorithm, tt.operator, tt.value, r.State, tt.want, r.Errors())
+        }
+    }
+}
@@ -0,0 +1,137 @@
+package policy
+
+import (
+    "context"
+    "fmt"
+
+    "dtctl/pkg/dependencytrack"
+)
+
+// ScopedProject is a project a policy applies to.
+type ScopedProject struct {
+    dependencytrack.Project
+    // Reason tells why the policy applies, e.g. "tag prod".
+    Reason string
+}
+
+// IsGlobal reports whether p applies to every project, which is the case
+// when it is limited to neither projects nor tags.
+func IsGlobal(p dependencytrack.Policy) bool {
+    return len(p.Projects) == 0 && len(p.Tags) == 0
+}
+
+// Scope resolves the projects p applies to, with descendants and latest
+// versions as set on p. Each project is returned once, in the order found.
+func Scope(ctx context.Context, client dependencytrack.API, p dependencytrack.Policy) ([]ScopedProject, error) {
+    // The full project list is needed for global policies, and for the
+    // parents and latest versions of the others.
+    var all []dependencytrack.Project
+    if IsGlobal(p) || p.IncludeChildren || p.OnlyLatestProjectVersion {
+        var err error
+        if all, err = client.GetProjectsContext(ctx); err != nil {
+            return nil, fmt.Errorf("failed to get projects: %w", err)
+        }
+    }
+    byUUID := map[string]dependencytrack.Project{}
+    for _, project := range all {
+        byUUID[project.UUID] = project
+    }
+
+    var scope []ScopedProject
+    seen := map[string]bool{}
+    add := func(project dependencytrack.Project, reason string) {
+        if seen[project.UUID] {
+            return
+        }
+        seen[project.UUID] = true
+        if full, ok := byUUID[project.UUID]; ok {
+            project = full
+        }
+        scope = append(scope, ScopedProject{Project: project, Reason: reason})
+    }
+
+    if IsGlobal(p) {
+        for _, project := range all {
+            add(project, "global policy")
+        }
+    }
+    assigned := map[string]bool{}
+    for _, project := range p.Projects {
+        assigned[project.UUID] = true
+        add(project, "assigned")
+    }
+    if p.IncludeChildren {
+        for _, project := range all {
+            if ancestor, ok := assignedAncestor(project, byUUID, assigned); ok {
+                add(project, "child of "+ancestor.Name)
+            }
+        }
+    }
+    for _, tag := range p.Tags {
+        tagged, err := client.GetProjectsByTagContext(ctx, tag.Name)
+        if err != nil {
+            return nil, fmt.Errorf("failed to get projects with tag %s: %w", tag.Name, err)
+        }
+        for _, project := range tagged {
+            add(project, "tag "+tag.Name)
+        }
+    }
+
+    if !p.OnlyLatestProjectVersion {
+        return scope, nil
+    }
+    latest := latestVersions(all)
+    var kept []ScopedProject
+    for _, project := range scope {
+        if latest[project.UUID] {
+            kept = append(kept, project)
+        }
+    }
+    return kept, nil
+}
+
+// assignedAncestor returns the closest ancestor of project that is in
+// assigned.
+func assignedAncestor(project dependencytrack.Project, byUUID map[string]dependencytrack.Project, assigned map[string]bool) (dependencytrack.Project, bool) {
+    visited := map[string]bool{project.UUID: true}
+    for project.Parent != nil && !visited[project.Parent.UUID] {
+        parent, ok := byUUID[project.Parent.UUID]
+        if !ok {
+            parent = dependencytrack.Project{UUID: project.Parent.UUID, Name: project.Parent.Name}
+        }
+        if assigned[parent.UUID] {
+            return parent, true
+        }
+        visited[parent.UUID] = true
+        project = parent
+    }
+    return dependencytrack.Project{}, false
+}
+
+// latestVersions returns the UUIDs of the latest version of each project.
+// Servers before 4.12 do not mark the latest version; for projects without
+// a marked version the highest version is taken.
+func latestVersions(projects []dependencytrack.Project) map[string]bool {
+    latest := map[string]bool{}
+    marked := map[string]bool{}
+    for _, project := range projects {
+        if project.IsLatest {
+            latest[project.UUID] = true
+            marked[project.Name] = true
+        }
+    }
+    highest := map[string]dependencytrack.Project{}
+    for _, project := range projects {
+        if marked[project.Name] {
+            continue
+        }
+        h, ok := highest[project.Name]
+        if cmp, err := CompareVersions(EcosystemGeneric, project.Version, h.Version); !ok || (err == nil && cmp > 0) {
+            highest[project.Name] = project
+        }
+    }
+    for _, project := range highest {
+        latest[project.UUID] = true
+    }
+    return latest
+}
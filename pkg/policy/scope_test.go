@@ -0,0 +1,87 @@
+package policy
+
+import (
+    "context"
+    "reflect"
+    "testing"
+
+    "dtctl/pkg/dependencytrack"
+)
+
+// portfolioAPI serves a fixed list of projects.
+type portfolioAPI struct {
+    dependencytrack.API
+    projects []dependencytrack.Project
+}
+
+func (f *portfolioAPI) GetProjectsContext(ctx context.Context) ([]dependencytrack.Project, error) {
+    return f.projects, nil
+}
+
+func (f *portfolioAPI) GetProjectsByTagContext(ctx context.Context, tag string) ([]dependencytrack.Project, error) {
+    var tagged []dependencytrack.Project
+    for _, p := range f.projects {
+        for _, t := range p.Tags {
+            if t.Name == tag {
+                tagged = append(tagged, p)
+            }
+        }
+    }
+    return tagged, nil
+}
+
+func TestScope(t *testing.T) {
+    child := func(name, uuid string, parent dependencytrack.Project) dependencytrack.Project {
+        return dependencytrack.Project{Name: name, UUID: uuid, Version: "1.0", Parent: &dependencytrack.ProjectReference{UUID: parent.UUID, Name: parent.Name}}
+    }
+    shop := dependencytrack.Project{Name: "shop", UUID: "shop-2", Version: "2.0", IsLatest: true}
+    oldShop := dependencytrack.Project{Name: "shop", UUID: "shop-1", Version: "1.0"}
+    cart := child("cart", "cart", shop)
+    checkout := child("checkout", "checkout", cart)
+    // Servers before 4.12 do not mark the latest version.
+    blog := dependencytrack.Project{Name: "blog", UUID: "blog-10", Version: "1.10", Tags: []dependencytrack.Tag{{Name: "web"}}}
+    oldBlog := dependencytrack.Project{Name: "blog", UUID: "blog-9", Version: "1.9", Tags: []dependencytrack.Tag{{Name: "web"}}}
+    api := &portfolioAPI{projects: []dependencytrack.Project{oldShop, shop, cart, checkout, oldBlog, blog}}
+
+    tests := []struct {
+        name   string
+        policy dependencytrack.Policy
+        want   []string
+    }{
+        {"global", dependencytrack.Policy{}, []string{"shop-1 global policy", "shop-2 global policy", "cart global policy", "checkout global policy", "blog-9 global policy", "blog-10 global policy"}},
+        {"global latest", dependencytrack.Policy{OnlyLatestProjectVersion: true}, []string{"shop-2 global policy", "cart global policy", "checkout global policy", "blog-10 global policy"}},
+        {"projects", dependencytrack.Policy{Projects: []dependencytrack.Project{shop}}, []string{"shop-2 assigned"}},
+        {"children", dependencytrack.Policy{Projects: []dependencytrack.Project{shop}, IncludeChildren: true}, []string{"shop-2 assigned", "cart child of shop", "checkout child of shop"}},
+        {"tags", dependencytrack.Policy{Tags: []dependencytrack.Tag{{Name: "web"}}}, []string{"blog-9 tag web", "blog-10 tag web"}},
+        {"tags latest", dependencytrack.Policy{Tags: []dependencytrack.Tag{{Name: "web"}}, OnlyLatestProjectVersion: true}, []string{"blog-10 tag web"}},
+        {"old version latest", dependencytrack.Policy{Projects: []dependencytrack.Project{oldShop}, OnlyLatestProjectVersion: true}, nil},
+        {"projects and tags", dependencytrack.Policy{Projects: []dependencytrack.Project{blog}, Tags: []dependencytrack.Tag{{Name: "web"}}}, []string{"blog-10 assigned", "blog-9 tag web"}},
+    }
+    for _, tt := range tests {
+        scope, err := Scope(context.Background(), api, tt.policy)
+        if err != nil {
+            t.Fatalf("%s: %v", tt.name, err)
+        }
+        var got []string
+        for _, p := range scope {
+            got = append(got, p.UUID+" "+p.Reason)
+        }
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s: scope = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestScopeIgnoresParentCycles(t *testing.T) {
+    a := dependencytrack.Project{Name: "a", UUID: "a", Parent: &dependencytrack.ProjectReference{UUID: "b"}}
+    b := dependencytrack.Project{Name: "b", UUID: "b", Parent: &dependencytrack.ProjectReference{UUID: "a"}}
+    root := dependencytrack.Project{Name: "root", UUID: "root"}
+    api := &portfolioAPI{projects: []dependencytrack.Project{a, b, root}}
+    scope, err := Scope(context.Background(), api, dependencytrack.Policy{Projects: []dependencytrack.Project{root}, IncludeChildren: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(scope) != 1 || scope[0].UUID != "root" {
+        t.Errorf("scope = %+v, want only root", scope)
+    }
+}
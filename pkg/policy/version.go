@@ -0,0 +1,625 @@
+package policy
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+// Version ecosystems, which decide how two versions of a component compare.
+const (
+    EcosystemGeneric = "generic"
+    EcosystemSemver  = "semver"
+    EcosystemMaven   = "maven"
+    EcosystemPyPI    = "pypi"
+    EcosystemDebian  = "deb"
+    EcosystemRPM     = "rpm"
+)
+
+// purlEcosystems maps package URL types to the ecosystem of their versions.
+// Types not listed are compared as EcosystemGeneric.
+var purlEcosystems = map[string]string{
+    "maven":     EcosystemMaven,
+    "npm":       EcosystemSemver,
+    "golang":    EcosystemSemver,
+    "cargo":     EcosystemSemver,
+    "nuget":     EcosystemSemver,
+    "composer":  EcosystemSemver,
+    "hex":       EcosystemSemver,
+    "pub":       EcosystemSemver,
+    "cocoapods": EcosystemSemver,
+    "swift":     EcosystemSemver,
+    "pypi":      EcosystemPyPI,
+    "deb":       EcosystemDebian,
+    "rpm":       EcosystemRPM,
+}
+
+// Ecosystem returns the version ecosystem of a package URL, e.g. maven for
+// pkg:maven/org.apache/commons@1.0.
+func Ecosystem(purl string) string {
+    if !strings.HasPrefix(purl, "pkg:") {
+        return EcosystemGeneric
+    }
+    typ := strings.TrimPrefix(purl, "pkg:")
+    if i := strings.IndexByte(typ, '/'); i >= 0 {
+        typ = typ[:i]
+    }
+    if e, ok := purlEcosystems[strings.ToLower(typ)]; ok {
+        return e
+    }
+    return EcosystemGeneric
+}
+
+// CompareVersions compares two versions by the rules of ecosystem and returns
+// -1, 0 or 1 as a is older than, the same as or newer than b.
+//
+// semver is lenient: a leading "v" and any number of release segments are
+// accepted. maven follows Maven's ComparableVersion, pypi PEP 440, deb dpkg
+// and rpm rpmvercmp. generic splits versions into numeric and alphabetic
+// runs; a trailing alphabetic run marks a pre-release, so 1.0rc1 is older
+// than 1.0.
+func CompareVersions(ecosystem, a, b string) (int, error) {
+    switch ecosystem {
+    case EcosystemSemver:
+        return compareSemver(a, b)
+    case EcosystemMaven:
+        return compareMaven(a, b), nil
+    case EcosystemPyPI:
+        return comparePEP440(a, b)
+    case EcosystemDebian:
+        return compareDebian(a, b)
+    case EcosystemRPM:
+        return compareRPM(a, b), nil
+    case EcosystemGeneric, "":
+        return compareGeneric(a, b), nil
+    }
+    return 0, fmt.Errorf("unknown version ecosystem %s", ecosystem)
+}
+
+// sign returns -1, 0 or 1 for a negative, zero or positive n.
+func sign(n int) int {
+    switch {
+    case n < 0:
+        return -1
+    case n > 0:
+        return 1
+    }
+    return 0
+}
+
+// compareNumeric compares two runs of digits of any length.
+func compareNumeric(a, b string) int {
+    a = strings.TrimLeft(a, "0")
+    b = strings.TrimLeft(b, "0")
+    if len(a) != len(b) {
+        return sign(len(a) - len(b))
+    }
+    return strings.Compare(a, b)
+}
+
+func isNumeric(s string) bool {
+    if s == "" {
+        return false
+    }
+    for _, r := range s {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+    return true
+}
+
+// semver
+
+type semver struct {
+    release    []string
+    prerelease []string
+}
+
+func parseSemver(v string) (semver, error) {
+    s := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(v), "="), "v")
+    if i := strings.IndexByte(s, '+'); i >= 0 {
+        s = s[:i]
+    }
+    var sv semver
+    if i := strings.IndexByte(s, '-'); i >= 0 {
+        sv.prerelease = strings.Split(s[i+1:], ".")
+        s = s[:i]
+    }
+    sv.release = strings.Split(s, ".")
+    for _, n := range sv.release {
+        if !isNumeric(n) {
+            return sv, fmt.Errorf("invalid version %q", v)
+        }
+    }
+    return sv, nil
+}
+
+func compareSemver(a, b string) (int, error) {
+    va, err := parseSemver(a)
+    if err != nil {
+        return 0, err
+    }
+    vb, err := parseSemver(b)
+    if err != nil {
+        return 0, err
+    }
+    for i := 0; i < len(va.release) || i < len(vb.release); i++ {
+        na, nb := "0", "0"
+        if i < len(va.release) {
+            na = va.release[i]
+        }
+        if i < len(vb.release) {
+            nb = vb.release[i]
+        }
+        if c := compareNumeric(na, nb); c != 0 {
+            return c, nil
+        }
+    }
+    // A release is newer than its pre-releases.
+    switch {
+    case len(va.prerelease) == 0 && len(vb.prerelease) == 0:
+        return 0, nil
+    case len(va.prerelease) == 0:
+        return 1, nil
+    case len(vb.prerelease) == 0:
+        return -1, nil
+    }
+    for i := 0; i < len(va.prerelease) && i < len(vb.prerelease); i++ {
+        pa, pb := va.prerelease[i], vb.prerelease[i]
+        var c int
+        switch {
+        case isNumeric(pa) && isNumeric(pb):
+            c = compareNumeric(pa, pb)
+        case isNumeric(pa):
+            c = -1
+        case isNumeric(pb):
+            c = 1
+        default:
+            c = strings.Compare(pa, pb)
+        }
+        if c != 0 {
+            return c, nil
+        }
+    }
+    return sign(len(va.prerelease) - len(vb.prerelease)), nil
+}
+
+// maven
+
+// mavenQualifiers ranks the qualifiers Maven knows; a release ranks as "".
+var mavenQualifiers = map[string]int{
+    "alpha":     0,
+    "beta":      1,
+    "milestone": 2,
+    "rc":        3,
+    "cr":        3,
+    "snapshot":  4,
+    "":          5,
+    "ga":        5,
+    "final":     5,
+    "release":   5,
+    "sp":        6,
+}
+
+// mavenItem is a segment of a Maven version: a number or a qualifier.
+type mavenItem struct {
+    value   string
+    numeric bool
+}
+
+func parseMaven(v string) []mavenItem {
+    v = strings.ToLower(strings.TrimSpace(v))
+    var items []mavenItem
+    start := 0
+    flush := func(end int) {
+        s := v[start:end]
+        items = append(items, mavenItem{value: s, numeric: isNumeric(s)})
+    }
+    for i := 0; i < len(v); i++ {
+        c := v[i]
+        switch {
+        case c == '.' || c == '-' || c == '_':
+            flush(i)
+            start = i + 1
+        case i > start && isDigit(c) != isDigit(v[i-1]):
+            // "1a2" is 1, a, 2; "a1", "b1" and "m1" are short for
+            // alpha-1, beta-1 and milestone-1.
+            flush(i)
+            start = i
+        }
+    }
+    flush(len(v))
+
+    for i := range items {
+        if items[i].numeric {
+            continue
+        }
+        if i+1 < len(items) && items[i+1].numeric {
+            switch items[i].value {
+            case "a":
+                items[i].value = "alpha"
+            case "b":
+                items[i].value = "beta"
+            case "m":
+                items[i].value = "milestone"
+            }
+        }
+    }
+    // Trailing zeros and release qualifiers do not count: 1.0.0 is 1.
+    for len(items) > 0 && mavenNull(items[len(items)-1]) {
+        items = items[:len(items)-1]
+    }
+    return items
+}
+
+func isDigit(c byte) bool {
+    return c >= '0' && c <= '9'
+}
+
+// mavenNull reports whether an item is equivalent to a missing one.
+func mavenNull(it mavenItem) bool {
+    if it.numeric {
+        return strings.TrimLeft(it.value, "0") == ""
+    }
+    return mavenQualifiers[it.value] == mavenQualifiers[""] && mavenKnown(it.value)
+}
+
+func mavenKnown(q string) bool {
+    _, ok := mavenQualifiers[q]
+    return ok
+}
+
+// compareMavenItems compares two items; the zero mavenItem stands for a
+// missing one, which equals 0 and a release.
+func compareMavenItems(a, b mavenItem) int {
+    missing := mavenItem{}
+    switch {
+    case a.numeric && b == missing:
+        return compareNumeric(a.value, "0")
+    case a == missing && b.numeric:
+        return compareNumeric("0", b.value)
+    case a.numeric && b.numeric:
+        return compareNumeric(a.value, b.value)
+    case a.numeric:
+        return 1
+    case b.numeric:
+        return -1
+    }
+    ka, kb := mavenKnown(a.value), mavenKnown(b.value)
+    switch {
+    case ka && kb:
+        return sign(mavenQualifiers[a.value] - mavenQualifiers[b.value])
+    case ka:
+        // Unknown qualifiers come after the known ones.
+        return -1
+    case kb:
+        return 1
+    }
+    return strings.Compare(a.value, b.value)
+}
+
+func compareMaven(a, b string) int {
+    ia, ib := parseMaven(a), parseMaven(b)
+    for i := 0; i < len(ia) || i < len(ib); i++ {
+        var x, y mavenItem
+        if i < len(ia) {
+            x = ia[i]
+        }
+        if i < len(ib) {
+            y = ib[i]
+        }
+        if c := compareMavenItems(x, y); c != 0 {
+            return c
+        }
+    }
+    return 0
+}
+
+// PEP 440
+
+var pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+    `(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d+)?)?` +
+    `(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d+)?)?` +
+    `(?:[-_.]?(dev)[-_.]?(\d+)?)?` +
+    `(?:\+[a-z0-9]+(?:[-_.][a-z0-9]+)*)?$`)
+
+// pep440 is a version reduced to a key that compares part by part. Missing
+// pre, post and dev parts hold sentinels so that 1.0.dev1 < 1.0a1 < 1.0 <
+// 1.0.post1.
+type pep440 struct {
+    epoch   string
+    release []string
+    pre     [2]int
+    post    int
+    dev     int
+}
+
+const (
+    pep440Lowest  = -1
+    pep440Highest = 1 << 30
+)
+
+func parsePEP440(v string) (pep440, error) {
+    m := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(v)))
+    if m == nil {
+        return pep440{}, fmt.Errorf("invalid version %q", v)
+    }
+    number := func(s string) int {
+        n, _ := strconv.Atoi(s)
+        return n
+    }
+    p := pep440{epoch: m[1], release: strings.Split(m[2], ".")}
+    if p.epoch == "" {
+        p.epoch = "0"
+    }
+    for len(p.release) > 1 && strings.TrimLeft(p.release[len(p.release)-1], "0") == "" {
+        p.release = p.release[:len(p.release)-1]
+    }
+
+    hasPost := m[5] != "" || m[6] != ""
+    hasDev := m[8] != ""
+    switch m[3] {
+    case "a", "alpha":
+        p.pre = [2]int{0, number(m[4])}
+    case "b", "beta":
+        p.pre = [2]int{1, number(m[4])}
+    case "c", "rc", "pre", "preview":
+        p.pre = [2]int{2, number(m[4])}
+    default:
+        p.pre = [2]int{pep440Highest, 0}
+        if !hasPost && hasDev {
+            // A dev release of a final release comes before its
+            // pre-releases.
+            p.pre = [2]int{pep440Lowest, 0}
+        }
+    }
+    switch {
+    case m[5] != "":
+        p.post = number(m[5])
+    case m[6] != "":
+        p.post = number(m[7])
+    default:
+        p.post = pep440Lowest
+    }
+    p.dev = pep440Highest
+    if hasDev {
+        p.dev = number(m[9])
+    }
+    return p, nil
+}
+
+func comparePEP440(a, b string) (int, error) {
+    pa, err := parsePEP440(a)
+    if err != nil {
+        return 0, err
+    }
+    pb, err := parsePEP440(b)
+    if err != nil {
+        return 0, err
+    }
+    if c := compareNumeric(pa.epoch, pb.epoch); c != 0 {
+        return c, nil
+    }
+    for i := 0; i < len(pa.release) || i < len(pb.release); i++ {
+        na, nb := "0", "0"
+        if i < len(pa.release) {
+            na = pa.release[i]
+        }
+        if i < len(pb.release) {
+            nb = pb.release[i]
+        }
+        if c := compareNumeric(na, nb); c != 0 {
+            return c, nil
+        }
+    }
+    for _, c := range []int{
+        sign(pa.pre[0] - pb.pre[0]),
+        sign(pa.pre[1] - pb.pre[1]),
+        sign(pa.post - pb.post),
+        sign(pa.dev - pb.dev),
+    } {
+        if c != 0 {
+            return c, nil
+        }
+    }
+    return 0, nil
+}
+
+// Debian
+
+// splitEpoch splits an "epoch:version" into its parts; the epoch defaults to 0.
+func splitEpoch(v string) (string, string) {
+    if i := strings.IndexByte(v, ':'); i >= 0 {
+        return v[:i], v[i+1:]
+    }
+    return "0", v
+}
+
+func compareDebian(a, b string) (int, error) {
+    ea, va := splitEpoch(strings.TrimSpace(a))
+    eb, vb := splitEpoch(strings.TrimSpace(b))
+    if !isNumeric(ea) || !isNumeric(eb) {
+        return 0, fmt.Errorf("invalid epoch in version %q or %q", a, b)
+    }
+    if c := compareNumeric(ea, eb); c != 0 {
+        return c, nil
+    }
+    ua, ra := splitRevision(va)
+    ub, rb := splitRevision(vb)
+    if c := compareDpkg(ua, ub); c != 0 {
+        return c, nil
+    }
+    return compareDpkg(ra, rb), nil
+}
+
+// splitRevision splits a Debian version at its last hyphen into upstream
+// version and revision.
+func splitRevision(v string) (string, string) {
+    if i := strings.LastIndexByte(v, '-'); i >= 0 {
+        return v[:i], v[i+1:]
+    }
+    return v, ""
+}
+
+// dpkgOrder ranks a character of the non-digit part of a Debian version:
+// '~' before the end of the string, letters before other characters.
+func dpkgOrder(c byte) int {
+    switch {
+    case c == '~':
+        return -1
+    case isDigit(c):
+        return 0
+    case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+        return int(c)
+    }
+    return int(c) + 256
+}
+
+// compareDpkg is dpkg's verrevcmp.
+func compareDpkg(a, b string) int {
+    for a != "" || b != "" {
+        for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+            var ca, cb int
+            if a != "" {
+                ca = dpkgOrder(a[0])
+            }
+            if b != "" {
+                cb = dpkgOrder(b[0])
+            }
+            if ca != cb {
+                return sign(ca - cb)
+            }
+            a, b = a[1:], b[1:]
+        }
+        var na, nb string
+        na, a = leadingDigits(a)
+        nb, b = leadingDigits(b)
+        if c := compareNumeric(na, nb); c != 0 {
+            return c
+        }
+    }
+    return 0
+}
+
+func leadingDigits(s string) (string, string) {
+    i := 0
+    for i < len(s) && isDigit(s[i]) {
+        i++
+    }
+    return s[:i], s[i:]
+}
+
+// RPM
+
+func compareRPM(a, b string) int {
+    ea, va := splitEpoch(strings.TrimSpace(a))
+    eb, vb := splitEpoch(strings.TrimSpace(b))
+    if c := compareNumeric(ea, eb); c != 0 {
+        return c
+    }
+    ua, ra := splitRevision(va)
+    ub, rb := splitRevision(vb)
+    if c := rpmvercmp(ua, ub); c != 0 || ra == "" || rb == "" {
+        return c
+    }
+    return rpmvercmp(ra, rb)
+}
+
+// versionSegments splits a version into runs of digits and runs of letters,
+// dropping everything else except '~', which is its own segment.
+func versionSegments(v string) []string {
+    var segs []string
+    for i := 0; i < len(v); {
+        c := rune(v[i])
+        switch {
+        case c == '~':
+            segs = append(segs, "~")
+            i++
+        case unicode.IsDigit(c):
+            j := i
+            for j < len(v) && isDigit(v[j]) {
+                j++
+            }
+            segs = append(segs, v[i:j])
+            i = j
+        case unicode.IsLetter(c):
+            j := i
+            for j < len(v) && unicode.IsLetter(rune(v[j])) {
+                j++
+            }
+            segs = append(segs, v[i:j])
+            i = j
+        default:
+            i++
+        }
+    }
+    return segs
+}
+
+// rpmvercmp compares like rpm does: numbers are newer than letters, '~'
+// sorts before everything, and the version with segments left over is newer.
+func rpmvercmp(a, b string) int {
+    sa, sb := versionSegments(a), versionSegments(b)
+    for i := 0; i < len(sa) || i < len(sb); i++ {
+        switch {
+        case i < len(sa) && sa[i] == "~" && (i >= len(sb) || sb[i] != "~"):
+            return -1
+        case i < len(sb) && sb[i] == "~" && (i >= len(sa) || sa[i] != "~"):
+            return 1
+        case i >= len(sa):
+            return -1
+        case i >= len(sb):
+            return 1
+        }
+        if c := compareSegments(sa[i], sb[i]); c != 0 {
+            return c
+        }
+    }
+    return 0
+}
+
+func compareSegments(a, b string) int {
+    switch {
+    case isNumeric(a) && isNumeric(b):
+        return compareNumeric(a, b)
+    case isNumeric(a):
+        return 1
+    case isNumeric(b):
+        return -1
+    }
+    return strings.Compare(a, b)
+}
+
+// compareGeneric is like rpmvercmp, except that a version with only letters
+// left over is a pre-release and thus older.
+func compareGeneric(a, b string) int {
+    sa := versionSegments(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(a), "v")))
+    sb := versionSegments(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(b), "v")))
+    for i := 0; i < len(sa) || i < len(sb); i++ {
+        switch {
+        case i >= len(sa):
+            if isNumeric(sb[i]) && strings.TrimLeft(sb[i], "0") != "" {
+                return -1
+            }
+            if !isNumeric(sb[i]) {
+                return 1
+            }
+            continue
+        case i >= len(sb):
+            if isNumeric(sa[i]) && strings.TrimLeft(sa[i], "0") != "" {
+                return 1
+            }
+            if !isNumeric(sa[i]) {
+                return -1
+            }
+            continue
+        }
+        if c := compareSegments(sa[i], sb[i]); c != 0 {
+            return c
+        }
+    }
+    return 0
+}
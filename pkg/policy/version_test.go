@@ -0,0 +1,105 @@
+package policy
+
+import "testing"
+
+func TestEcosystem(t *testing.T) {
+    tests := map[string]string{
+        "pkg:maven/org.apache.logging.log4j/log4j-core@2.17.1": EcosystemMaven,
+        "pkg:npm/express@4.18.2":                               EcosystemSemver,
+        "pkg:golang/github.com/spf13/cobra@v1.8.0":             EcosystemSemver,
+        "pkg:PyPI/django@4.2":                                  EcosystemPyPI,
+        "pkg:deb/debian/openssl@3.0.11-1":                      EcosystemDebian,
+        "pkg:rpm/fedora/curl@8.2.1-3.fc39":                     EcosystemRPM,
+        "pkg:gem/rails@7.1.0":                                  EcosystemGeneric,
+        "":                                                     EcosystemGeneric,
+        "cpe:2.3:a:apache:log4j:2.17.1":                        EcosystemGeneric,
+    }
+    for purl, want := range tests {
+        if got := Ecosystem(purl); got != want {
+            t.Errorf("Ecosystem(%q) = %s, want %s", purl, got, want)
+        }
+    }
+}
+
+func TestCompareVersions(t *testing.T) {
+    tests := []struct {
+        ecosystem, a, b string
+        want            int
+    }{
+        {EcosystemSemver, "1.2.3", "1.2.3", 0},
+        {EcosystemSemver, "v1.2.3", "1.2.3", 0},
+        {EcosystemSemver, "1.10.0", "1.9.0", 1},
+        {EcosystemSemver, "1.2", "1.2.0", 0},
+        {EcosystemSemver, "1.0.0-rc.1", "1.0.0", -1},
+        {EcosystemSemver, "1.0.0-alpha", "1.0.0-alpha.1", -1},
+        {EcosystemSemver, "1.0.0-alpha.beta", "1.0.0-alpha.1", 1},
+        {EcosystemSemver, "1.0.0-beta.2", "1.0.0-beta.11", -1},
+        {EcosystemSemver, "1.0.0+build.5", "1.0.0", 0},
+
+        {EcosystemMaven, "2.17.1", "2.9.0", 1},
+        {EcosystemMaven, "1.0", "1.0.0", 0},
+        {EcosystemMaven, "1.0-SNAPSHOT", "1.0", -1},
+        {EcosystemMaven, "1.0-alpha-1", "1.0-beta-1", -1},
+        {EcosystemMaven, "1.0a1", "1.0-alpha-1", 0},
+        {EcosystemMaven, "1.0-rc1", "1.0-SNAPSHOT", -1},
+        {EcosystemMaven, "1.0.Final", "1.0", 0},
+        {EcosystemMaven, "1.0-sp1", "1.0", 1},
+        {EcosystemMaven, "1.0.1", "1.0", 1},
+        {EcosystemMaven, "2.15.2", "2.15.2-jre", -1},
+
+        {EcosystemPyPI, "1.0", "1.0.0", 0},
+        {EcosystemPyPI, "1.0a1", "1.0", -1},
+        {EcosystemPyPI, "1.0.dev1", "1.0a1", -1},
+        {EcosystemPyPI, "1.0rc1", "1.0b2", 1},
+        {EcosystemPyPI, "1.0.post1", "1.0", 1},
+        {EcosystemPyPI, "1.0-1", "1.0.post1", 0},
+        {EcosystemPyPI, "1!0.1", "2.0", 1},
+        {EcosystemPyPI, "1.0+local.1", "1.0", 0},
+        {EcosystemPyPI, "4.2.10", "4.2.9", 1},
+
+        {EcosystemDebian, "1.0-1", "1.0-2", -1},
+        {EcosystemDebian, "1.0~rc1-1", "1.0-1", -1},
+        {EcosystemDebian, "1:0.9-1", "2.0-1", 1},
+        {EcosystemDebian, "3.0.11-1~deb12u1", "3.0.11-1", -1},
+        {EcosystemDebian, "1.0a", "1.0+", -1},
+
+        {EcosystemRPM, "8.2.1-3.fc39", "8.2.1-10.fc39", -1},
+        {EcosystemRPM, "1.0~rc1", "1.0", -1},
+        {EcosystemRPM, "1.0a", "1.0", 1},
+        {EcosystemRPM, "2:1.0", "1:9.0", 1},
+        {EcosystemRPM, "1.0-1", "1.0", 0},
+
+        {EcosystemGeneric, "7.1.0", "7.0.8", 1},
+        {EcosystemGeneric, "1.0rc1", "1.0", -1},
+        {EcosystemGeneric, "1.0.0.pre", "1.0.0", -1},
+        {EcosystemGeneric, "1.0", "1.0.0", 0},
+        {EcosystemGeneric, "r10", "r9", 1},
+    }
+    for _, tt := range tests {
+        got, err := CompareVersions(tt.ecosystem, tt.a, tt.b)
+        if err != nil {
+            t.Errorf("CompareVersions(%s, %q, %q) failed: %v", tt.ecosystem, tt.a, tt.b, err)
+            continue
+        }
+        if got != tt.want {
+            t.Errorf("CompareVersions(%s, %q, %q) = %d, want %d", tt.ecosystem, tt.a, tt.b, got, tt.want)
+        }
+        if back, _ := CompareVersions(tt.ecosystem, tt.b, tt.a); back != -tt.want {
+            t.Errorf("CompareVersions(%s, %q, %q) = %d, want %d", tt.ecosystem, tt.b, tt.a, back, -tt.want)
+        }
+    }
+}
+
+func TestCompareVersionsErrors(t *testing.T) {
+    tests := []struct{ ecosystem, a, b string }{
+        {EcosystemSemver, "1.x", "1.0"},
+        {EcosystemPyPI, "1.0", "not a version"},
+        {EcosystemDebian, "x:1.0", "1.0"},
+        {"cobol", "1", "2"},
+    }
+    for _, tt := range tests {
+        if _, err := CompareVersions(tt.ecosystem, tt.a, tt.b); err == nil {
+            t.Errorf("CompareVersions(%s, %q, %q) succeeded, want an error", tt.ecosystem, tt.a, tt.b)
+        }
+    }
+}
@@ -0,0 +1,178 @@
+package policy
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "dtctl/pkg/dependencytrack"
+)
+
+// vulnerabilitySubjects are the subjects whose conditions are evaluated
+// against the findings of a component.
+var vulnerabilitySubjects = map[string]bool{
+    dependencytrack.SubjectCWE:             true,
+    dependencytrack.SubjectEPSS:            true,
+    dependencytrack.SubjectSeverity:        true,
+    dependencytrack.SubjectVulnerabilityID: true,
+}
+
+// NeedsFindings reports whether p has conditions on vulnerabilities, which
+// need the findings of the components in Target.
+func NeedsFindings(p dependencytrack.Policy) bool {
+    for _, c := range p.PolicyConditions {
+        if vulnerabilitySubjects[c.Subject] {
+            return true
+        }
+    }
+    return false
+}
+
+// vulnerabilities returns the vulnerabilities of the target's findings that
+// were not suppressed.
+func vulnerabilities(t Target) ([]dependencytrack.Vulnerability, error) {
+    if !t.FindingsLoaded {
+        return nil, fmt.Errorf("findings of component %s were not fetched", t.Component.Name)
+    }
+    var vulns []dependencytrack.Vulnerability
+    for _, f := range t.Findings {
+        if !f.Analysis.IsSuppressed {
+            vulns = append(vulns, f.Vulnerability)
+        }
+    }
+    return vulns, nil
+}
+
+// anyVulnerability matches a condition when match matches one of the
+// target's vulnerabilities.
+func anyVulnerability(t Target, match func(v dependencytrack.Vulnerability) bool) (bool, error) {
+    vulns, err := vulnerabilities(t)
+    if err != nil {
+        return false, err
+    }
+    for _, v := range vulns {
+        if match(v) {
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+var severities = []string{
+    dependencytrack.SeverityCritical,
+    dependencytrack.SeverityHigh,
+    dependencytrack.SeverityMedium,
+    dependencytrack.SeverityLow,
+    dependencytrack.SeverityInfo,
+    dependencytrack.SeverityUnassigned,
+}
+
+// severity matches a SEVERITY condition: IS matches a component with a
+// vulnerability of the severity, IS_NOT one with a vulnerability of another
+// severity. A component without vulnerabilities matches neither.
+func severity(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    want := strings.ToUpper(strings.TrimSpace(c.Value))
+    known := false
+    for _, s := range severities {
+        known = known || s == want
+    }
+    if !known {
+        return false, fmt.Errorf("value %q is not a severity; use one of %s", c.Value, strings.Join(severities, ", "))
+    }
+    if _, err := is(c.Operator, false); err != nil {
+        return false, err
+    }
+    return anyVulnerability(t, func(v dependencytrack.Vulnerability) bool {
+        equal := strings.EqualFold(v.Severity, want)
+        return equal == (c.Operator == dependencytrack.OperatorIs)
+    })
+}
+
+// vulnerabilityID matches a VULNERABILITY_ID condition like severity does,
+// comparing IDs such as CVE-2021-44228 regardless of case.
+func vulnerabilityID(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    want := strings.TrimSpace(c.Value)
+    if _, err := is(c.Operator, false); err != nil {
+        return false, err
+    }
+    return anyVulnerability(t, func(v dependencytrack.Vulnerability) bool {
+        equal := strings.EqualFold(v.VulnID, want)
+        return equal == (c.Operator == dependencytrack.OperatorIs)
+    })
+}
+
+// parseCWEs parses a list of CWEs such as "CWE-79, 89".
+func parseCWEs(value string) ([]int, error) {
+    var ids []int
+    for _, f := range strings.Split(value, ",") {
+        f = strings.TrimSpace(f)
+        if f == "" {
+            continue
+        }
+        id, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(f), "CWE-"))
+        if err != nil || id < 0 {
+            return nil, fmt.Errorf("value %q is not a list of CWEs", value)
+        }
+        ids = append(ids, id)
+    }
+    if len(ids) == 0 {
+        return nil, fmt.Errorf("value %q is not a list of CWEs", value)
+    }
+    return ids, nil
+}
+
+// cwe matches a CWE condition, whose value lists CWEs: CONTAINS_ANY matches
+// a component with a vulnerability classified as one of them, CONTAINS_ALL
+// one with a vulnerability classified as all of them.
+func cwe(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    want, err := parseCWEs(c.Value)
+    if err != nil {
+        return false, err
+    }
+    if c.Operator != dependencytrack.OperatorContainsAny && c.Operator != dependencytrack.OperatorContainsAll {
+        return false, fmt.Errorf("operator %s is not supported", c.Operator)
+    }
+    return anyVulnerability(t, func(v dependencytrack.Vulnerability) bool {
+        has := map[int]bool{}
+        for _, id := range v.CWEIDs() {
+            has[id] = true
+        }
+        found := 0
+        for _, id := range want {
+            if has[id] {
+                found++
+            }
+        }
+        if c.Operator == dependencytrack.OperatorContainsAll {
+            return found == len(want)
+        }
+        return found > 0
+    })
+}
+
+// epss matches an EPSS condition, comparing the EPSS scores of the
+// component's vulnerabilities with the condition's using the NUMERIC_*
+// operators. Vulnerabilities without a score are skipped.
+func epss(c dependencytrack.PolicyCondition, t Target) (bool, error) {
+    want, err := strconv.ParseFloat(strings.TrimSpace(c.Value), 64)
+    if err != nil {
+        return false, fmt.Errorf("value %q is not an EPSS score", c.Value)
+    }
+    if _, err := compare(c.Operator, 0); err != nil {
+        return false, err
+    }
+    return anyVulnerability(t, func(v dependencytrack.Vulnerability) bool {
+        if v.EPSSScore == nil {
+            return false
+        }
+        cmp := 0
+        switch {
+        case *v.EPSSScore > want:
+            cmp = 1
+        case *v.EPSSScore < want:
+            cmp = -1
+        }
+        ok, _ := compare(c.Operator, cmp)
+        return ok
+    })
+}
@@ -0,0 +1,130 @@
+package policy
+
+import (
+    "testing"
+
+    "dtctl/pkg/dependencytrack"
+)
+
+func score(f float64) *float64 { return &f }
+
+var (
+    log4shell = dependencytrack.Finding{Vulnerability: dependencytrack.Vulnerability{
+        VulnID:    "CVE-2021-44228",
+        Severity:  "CRITICAL",
+        CWEs:      []dependencytrack.CWE{{CWEID: 20}, {CWEID: 400}, {CWEID: 502}},
+        EPSSScore: score(0.97),
+    }}
+    lowFinding = dependencytrack.Finding{Vulnerability: dependencytrack.Vulnerability{
+        VulnID:   "GHSA-0000-0000-0001",
+        Severity: "LOW",
+        CWEID:    func() *int { id := 79; return &id }(),
+    }}
+    // suppressedFinding was audited as not affecting the component.
+    suppressedFinding = dependencytrack.Finding{
+        Vulnerability: dependencytrack.Vulnerability{VulnID: "CVE-2023-0001", Severity: "HIGH", CWEs: []dependencytrack.CWE{{CWEID: 89}}, EPSSScore: score(0.5)},
+        Analysis:      dependencytrack.Analysis{State: "NOT_AFFECTED", IsSuppressed: true},
+    }
+)
+
+// vulnTest is a case of a table-driven test of a vulnerability condition.
+type vulnTest struct {
+    operator, value string
+    findings        []dependencytrack.Finding
+    want            bool
+    wantErr         bool
+}
+
+func runVulnTests(t *testing.T, subject string, tests []vulnTest) {
+    t.Helper()
+    for _, tt := range tests {
+        c := dependencytrack.PolicyCondition{Operator: tt.operator, Subject: subject, Value: tt.value}
+        r := evaluateCondition(c, Target{Component: log4j, Findings: tt.findings, FindingsLoaded: true})
+        if (r.Err != nil) != tt.wantErr || r.Matched != tt.want {
+            t.Errorf("%s with %d findings = %v, %v, want %v, error %v", Describe(c), len(tt.findings), r.Matched, r.Err, tt.want, tt.wantErr)
+        }
+    }
+}
+
+func TestSeverity(t *testing.T) {
+    runVulnTests(t, dependencytrack.SubjectSeverity, []vulnTest{
+        {"IS", "CRITICAL", []dependencytrack.Finding{lowFinding, log4shell}, true, false},
+        {"IS", "critical", []dependencytrack.Finding{log4shell}, true, false},
+        {"IS", "HIGH", []dependencytrack.Finding{lowFinding, log4shell}, false, false},
+        {"IS_NOT", "CRITICAL", []dependencytrack.Finding{lowFinding, log4shell}, true, false},
+        {"IS_NOT", "CRITICAL", []dependencytrack.Finding{log4shell}, false, false},
+        // Suppressed findings do not count.
+        {"IS", "HIGH", []dependencytrack.Finding{suppressedFinding}, false, false},
+        {"IS_NOT", "CRITICAL", []dependencytrack.Finding{suppressedFinding}, false, false},
+        // Without vulnerabilities neither IS nor IS_NOT match.
+        {"IS", "CRITICAL", nil, false, false},
+        {"IS_NOT", "CRITICAL", nil, false, false},
+        {"IS", "SEVERE", []dependencytrack.Finding{log4shell}, false, true},
+        {"MATCHES", "CRITICAL", []dependencytrack.Finding{log4shell}, false, true},
+    })
+}
+
+func TestVulnerabilityID(t *testing.T) {
+    runVulnTests(t, dependencytrack.SubjectVulnerabilityID, []vulnTest{
+        {"IS", "CVE-2021-44228", []dependencytrack.Finding{lowFinding, log4shell}, true, false},
+        {"IS", "cve-2021-44228", []dependencytrack.Finding{log4shell}, true, false},
+        {"IS", "CVE-2021-45046", []dependencytrack.Finding{log4shell}, false, false},
+        {"IS_NOT", "CVE-2021-44228", []dependencytrack.Finding{log4shell}, false, false},
+        {"IS_NOT", "CVE-2021-44228", []dependencytrack.Finding{lowFinding, log4shell}, true, false},
+        {"IS", "CVE-2023-0001", []dependencytrack.Finding{suppressedFinding}, false, false},
+        {"IS_NOT", "CVE-2021-44228", nil, false, false},
+        {"NUMERIC_EQUAL", "CVE-2021-44228", []dependencytrack.Finding{log4shell}, false, true},
+    })
+}
+
+func TestCWE(t *testing.T) {
+    runVulnTests(t, dependencytrack.SubjectCWE, []vulnTest{
+        {"CONTAINS_ANY", "CWE-502", []dependencytrack.Finding{log4shell}, true, false},
+        {"CONTAINS_ANY", "79, 89", []dependencytrack.Finding{log4shell}, false, false},
+        // The CWE of older servers' findings counts too.
+        {"CONTAINS_ANY", "79, 89", []dependencytrack.Finding{log4shell, lowFinding}, true, false},
+        {"CONTAINS_ALL", "CWE-20,CWE-502", []dependencytrack.Finding{log4shell}, true, false},
+        // Every CWE must be found on a single vulnerability.
+        {"CONTAINS_ALL", "CWE-502, CWE-79", []dependencytrack.Finding{log4shell, lowFinding}, false, false},
+        {"CONTAINS_ANY", "CWE-89", []dependencytrack.Finding{suppressedFinding}, false, false},
+        {"CONTAINS_ANY", "CWE-89", nil, false, false},
+        {"CONTAINS_ANY", "XSS", []dependencytrack.Finding{log4shell}, false, true},
+        {"CONTAINS_ANY", "", []dependencytrack.Finding{log4shell}, false, true},
+        {"IS", "CWE-502", []dependencytrack.Finding{log4shell}, false, true},
+    })
+}
+
+func TestEPSS(t *testing.T) {
+    runVulnTests(t, dependencytrack.SubjectEPSS, []vulnTest{
+        {"NUMERIC_GREATER_THAN", "0.9", []dependencytrack.Finding{lowFinding, log4shell}, true, false},
+        {"NUMERIC_GREATER_THAN", "0.97", []dependencytrack.Finding{log4shell}, false, false},
+        {"NUMERIC_GREATER_THAN_OR_EQUAL", "0.97", []dependencytrack.Finding{log4shell}, true, false},
+        {"NUMERIC_EQUAL", "0.97", []dependencytrack.Finding{log4shell}, true, false},
+        {"NUMERIC_NOT_EQUAL", "0.97", []dependencytrack.Finding{log4shell}, false, false},
+        {"NUMERIC_LESS_THAN", "0.1", []dependencytrack.Finding{log4shell}, false, false},
+        {"NUMERIC_LESSER_THAN_OR_EQUAL", "1", []dependencytrack.Finding{log4shell}, true, false},
+        // Vulnerabilities without a score are skipped.
+        {"NUMERIC_LESS_THAN", "0.1", []dependencytrack.Finding{lowFinding}, false, false},
+        {"NUMERIC_GREATER_THAN", "0.1", []dependencytrack.Finding{suppressedFinding}, false, false},
+        {"NUMERIC_GREATER_THAN", "high", []dependencytrack.Finding{log4shell}, false, true},
+        {"IS", "0.97", []dependencytrack.Finding{log4shell}, false, true},
+    })
+}
+
+func TestVulnerabilityConditionsNeedFindings(t *testing.T) {
+    p := dependencytrack.Policy{PolicyConditions: []dependencytrack.PolicyCondition{
+        {Operator: "IS", Subject: dependencytrack.SubjectSeverity, Value: "CRITICAL"},
+    }}
+    if !NeedsFindings(p) {
+        t.Errorf("NeedsFindings = false for a SEVERITY condition")
+    }
+    if NeedsFindings(dependencytrack.Policy{PolicyConditions: []dependencytrack.PolicyCondition{hash("IS", sumA)}}) {
+        t.Errorf("NeedsFindings = true for a COMPONENT_HASH condition")
+    }
+    if r := Evaluate(p, Target{Component: log4j}); r.State != Unknown {
+        t.Errorf("State without findings = %s, want %s", r.State, Unknown)
+    }
+    if r := Evaluate(p, Target{Component: log4j, FindingsLoaded: true}); r.State != NotViolated {
+        t.Errorf("State without vulnerabilities = %s, want %s", r.State, NotViolated)
+    }
+}